@@ -0,0 +1,19 @@
+package exportdto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DataExportDTO struct {
+	ID                   primitive.ObjectID `json:"id"`
+	Status               string             `json:"status"`
+	Error                string             `json:"error,omitempty"`
+	Size                 int64              `json:"size,omitempty"`
+	CreatedAt            *time.Time         `json:"created_at"`
+	CompletedAt          *time.Time         `json:"completed_at,omitempty"`
+	ExpiresAt            time.Time          `json:"expires_at"`
+	DownloadURL          string             `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time         `json:"download_url_expires_at,omitempty"`
+}
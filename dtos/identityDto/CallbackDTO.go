@@ -0,0 +1,10 @@
+package identitydto
+
+type CallbackDTO struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=256"`
+}
+
+type AuthorizationURLDTO struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
@@ -0,0 +1,11 @@
+package identitydto
+
+import "time"
+
+type IdentityDTO struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	CreatedAt   *time.Time `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
@@ -0,0 +1,24 @@
+package oauthdto
+
+import "time"
+
+type CreateClientDTO struct {
+	Name         string   `json:"name" validate:"required,min=1,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,max=10,dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=tasks:read tasks:write account:read"`
+	Confidential bool     `json:"confidential"`
+}
+
+type ClientDTO struct {
+	ClientID     string     `json:"client_id"`
+	Name         string     `json:"name"`
+	RedirectURIs []string   `json:"redirect_uris"`
+	Scopes       []string   `json:"scopes"`
+	Confidential bool       `json:"confidential"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+type CreatedClientDTO struct {
+	ClientDTO
+	ClientSecret string `json:"client_secret,omitempty"`
+}
@@ -0,0 +1,16 @@
+package securitydto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecurityEventDTO struct {
+	ID        primitive.ObjectID `json:"id"`
+	Type      string             `json:"type"`
+	IP        string             `json:"ip"`
+	UserAgent string             `json:"user_agent"`
+	Details   map[string]string  `json:"details,omitempty"`
+	CreatedAt *time.Time         `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package sessiondto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionDTO struct {
+	ID         primitive.ObjectID `json:"id"`
+	UserAgent  string             `json:"user_agent"`
+	IP         string             `json:"ip"`
+	ClientID   string             `json:"client_id,omitempty"`
+	CreatedAt  *time.Time         `json:"created_at"`
+	LastUsedAt *time.Time         `json:"last_used_at"`
+	Current    bool               `json:"current"`
+}
@@ -0,0 +1,21 @@
+package tokendto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalAccessTokenDTO struct {
+	ID         primitive.ObjectID `json:"id"`
+	Name       string             `json:"name"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  *time.Time         `json:"expires_at"`
+	LastUsedAt *time.Time         `json:"last_used_at"`
+	CreatedAt  *time.Time         `json:"created_at"`
+}
+
+type CreatedPersonalAccessTokenDTO struct {
+	Token string `json:"token"`
+	PersonalAccessTokenDTO
+}
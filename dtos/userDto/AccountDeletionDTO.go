@@ -0,0 +1,15 @@
+package userDto
+
+import "time"
+
+type AccountDeletionDTO struct {
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+type RequestRecoveryDTO struct {
+	Email string `json:"email" validate:"required,email,min=10,max=150"`
+}
+
+type RecoverAccountDTO struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,12 @@
+package userDto
+
+import "time"
+
+type AdminUserDTO struct {
+	UserDTO
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+	PurgeAt    *time.Time `json:"purge_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
@@ -0,0 +1,6 @@
+package userDto
+
+type ChangeEmailDTO struct {
+	Email           string `json:"email" validate:"required,email,min=10,max=150"`
+	CurrentPassword string `json:"current_password" validate:"required,max=128"`
+}
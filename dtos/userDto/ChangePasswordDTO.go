@@ -0,0 +1,6 @@
+package userDto
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=128"`
+	NewPassword     string `json:"new_password" validate:"required,max=128"`
+}
@@ -0,0 +1,5 @@
+package userDto
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" validate:"required,email,min=10,max=150"`
+}
@@ -0,0 +1,10 @@
+package userDto
+
+import "time"
+
+type ImpersonationDTO struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	User        UserDTO   `json:"user"`
+}
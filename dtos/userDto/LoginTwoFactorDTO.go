@@ -0,0 +1,6 @@
+package userDto
+
+type LoginTwoFactorDTO struct {
+	MfaToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,min=6,max=20"`
+}
@@ -0,0 +1,9 @@
+package userDto
+
+type MagicLinkRequestDTO struct {
+	Email string `json:"email" validate:"required,email,min=10,max=150"`
+}
+
+type MagicLinkRedeemDTO struct {
+	Token string `json:"token" validate:"required"`
+}
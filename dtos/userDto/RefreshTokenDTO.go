@@ -0,0 +1,5 @@
+package userDto
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
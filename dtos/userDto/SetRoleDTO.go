@@ -0,0 +1,5 @@
+package userDto
+
+type SetRoleDTO struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
@@ -0,0 +1,5 @@
+package userDto
+
+type TwoFactorCodeDTO struct {
+	Code string `json:"code" validate:"required,min=6,max=20"`
+}
@@ -2,5 +2,4 @@ package userDto
 
 type UpdateUserDTO struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=6,max=50"`
-}
\ No newline at end of file
+}
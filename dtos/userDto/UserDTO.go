@@ -7,8 +7,11 @@ import (
 )
 
 type UserDTO struct {
-	ID           primitive.ObjectID `json:"id,omitempty"`
-	Username     string             `json:"username" `
-	Email        string             `json:"email" `
-	CreatedAt    *time.Time         `json:"created_at" `
-}
\ No newline at end of file
+	ID            primitive.ObjectID `json:"id,omitempty"`
+	Username      string             `json:"username" `
+	Email         string             `json:"email" `
+	EmailVerified bool               `json:"email_verified"`
+	TOTPEnabled   bool               `json:"totp_enabled"`
+	Role          string             `json:"role"`
+	CreatedAt     *time.Time         `json:"created_at" `
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+	dto "todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/user"
+	"todolist-auth-fiber/utils/pagination"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminHandler interface {
+	GetUsers(c *fiber.Ctx) error
+	GetUser(c *fiber.Ctx) error
+	DisableUser(c *fiber.Ctx) error
+	EnableUser(c *fiber.Ctx) error
+	SetRole(c *fiber.Ctx) error
+	ForceLogout(c *fiber.Ctx) error
+	TaskCounts(c *fiber.Ctx) error
+	GetAuditLogs(c *fiber.Ctx) error
+	OrphanedTasks(c *fiber.Ctx) error
+	GetSecurityEvents(c *fiber.Ctx) error
+	Impersonate(c *fiber.Ctx) error
+}
+
+type adminHandler struct {
+	service        services.AdminService
+	auditService   services.AuditService
+	consistency    services.ConsistencyService
+	securityEvents services.SecurityEventService
+}
+
+func NewAdminHandler(
+	service services.AdminService,
+	auditService services.AuditService,
+	consistency services.ConsistencyService,
+	securityEvents services.SecurityEventService,
+) AdminHandler {
+	return &adminHandler{
+		service:        service,
+		auditService:   auditService,
+		consistency:    consistency,
+		securityEvents: securityEvents,
+	}
+}
+
+func (h *adminHandler) GetUsers(c *fiber.Ctx) error {
+	query := c.Query("q", "")
+	page, pageSize := pageParams(c)
+
+	if err := h.audit(c, models.AuditAdminUsersList, nil, map[string]string{"q": query}); err != nil {
+		return err
+	}
+
+	users, total, err := h.service.SearchUsers(c.Context(), query, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	dtos := []dto.AdminUserDTO{}
+	for i := range users {
+		dtos = append(dtos, mappers.UserToAdminUserDTO(&users[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[pagination.Page[dto.AdminUserDTO]]{
+			Timestamp: time.Now(),
+			Body: pagination.Page[dto.AdminUserDTO]{
+				Items:     dtos,
+				Total:     total,
+				PageIndex: page,
+				PageSize:  pageSize,
+			},
+			Code:    fiber.StatusOK,
+			Status:  true,
+			Message: "Users retrieved successfully",
+		},
+	)
+}
+
+func (h *adminHandler) GetUser(c *fiber.Ctx) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.audit(c, models.AuditAdminUsersRead, &id, nil); err != nil {
+		return err
+	}
+
+	user, err := h.service.GetUser(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.AdminUserDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.UserToAdminUserDTO(user),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "User retrieved successfully",
+		},
+	)
+}
+
+func (h *adminHandler) DisableUser(c *fiber.Ctx) error {
+	return h.setDisabled(c, true)
+}
+
+func (h *adminHandler) EnableUser(c *fiber.Ctx) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *adminHandler) setDisabled(c *fiber.Ctx, disabled bool) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	action := models.AuditAdminUsersEnable
+	if disabled {
+		action = models.AuditAdminUsersDisable
+	}
+
+	if err := h.audit(c, action, &id, nil); err != nil {
+		return err
+	}
+
+	user, err := h.service.SetDisabled(c.Context(), middleware.User(c), id, disabled)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.AdminUserDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.UserToAdminUserDTO(user),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "User status updated with successfully",
+		},
+	)
+}
+
+func (h *adminHandler) SetRole(c *fiber.Ctx) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	var req dto.SetRoleDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	if err := h.audit(c, models.AuditAdminUsersRole, &id, map[string]string{"role": req.Role}); err != nil {
+		return err
+	}
+
+	user, err := h.service.SetRole(c.Context(), middleware.User(c), id, req.Role)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.AdminUserDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.UserToAdminUserDTO(user),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "User role updated with successfully",
+		},
+	)
+}
+
+func (h *adminHandler) ForceLogout(c *fiber.Ctx) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.audit(c, models.AuditAdminSessionsRevoke, &id, nil); err != nil {
+		return err
+	}
+
+	revoked, err := h.service.ForceLogout(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[int64]{
+			Timestamp: time.Now(),
+			Body:      revoked,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Sessions revoked with successfully!",
+		},
+	)
+}
+
+// Impersonate mints a token to act as the user. Every request made with it is audited and
+// destructive account routes refuse it.
+func (h *adminHandler) Impersonate(c *fiber.Ctx) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.audit(c, models.AuditAdminImpersonate, &id, nil); err != nil {
+		return err
+	}
+
+	impersonation, err := h.service.Impersonate(c.Context(), middleware.User(c), id)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityImpersonationStarted, impersonation.User, "", map[string]string{
+		"expires_at": impersonation.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.ImpersonationDTO]{
+			Timestamp: time.Now(),
+			Body: dto.ImpersonationDTO{
+				AccessToken: impersonation.Token,
+				TokenType:   "Bearer",
+				ExpiresAt:   impersonation.ExpiresAt,
+				User:        mappers.UserToUserDTO(impersonation.User),
+			},
+			Code:    fiber.StatusOK,
+			Status:  true,
+			Message: "Impersonation token issued",
+		},
+	)
+}
+
+func (h *adminHandler) TaskCounts(c *fiber.Ctx) error {
+	id, err := targetID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.audit(c, models.AuditAdminTasksCount, &id, nil); err != nil {
+		return err
+	}
+
+	counts, err := h.service.TaskCounts(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[services.TaskCounts]{
+			Timestamp: time.Now(),
+			Body:      *counts,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Task counts retrieved successfully",
+		},
+	)
+}
+
+func (h *adminHandler) OrphanedTasks(c *fiber.Ctx) error {
+	if err := h.audit(c, models.AuditAdminConsistencyRead, nil, nil); err != nil {
+		return err
+	}
+
+	report, err := h.consistency.OrphanedTasks(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[services.OrphanReport]{
+			Timestamp: time.Now(),
+			Body:      *report,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Orphaned tasks checked successfully",
+		},
+	)
+}
+
+func (h *adminHandler) GetAuditLogs(c *fiber.Ctx) error {
+	page, pageSize := pageParams(c)
+
+	actorID, err := optionalObjectID(c, "actor_id")
+	if err != nil {
+		return err
+	}
+
+	targetID, err := optionalObjectID(c, "target_id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.audit(c, models.AuditAdminAuditRead, targetID, nil); err != nil {
+		return err
+	}
+
+	entries, total, err := h.auditService.GetAll(c.Context(), actorID, targetID, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[pagination.Page[models.AuditLog]]{
+			Timestamp: time.Now(),
+			Body: pagination.Page[models.AuditLog]{
+				Items:     entries,
+				Total:     total,
+				PageIndex: page,
+				PageSize:  pageSize,
+			},
+			Code:    fiber.StatusOK,
+			Status:  true,
+			Message: "Audit logs retrieved successfully",
+		},
+	)
+}
+
+func (h *adminHandler) GetSecurityEvents(c *fiber.Ctx) error {
+	page, pageSize := pageParams(c)
+	eventType := c.Query("type")
+	email := c.Query("email")
+	ip := c.Query("ip")
+
+	userID, err := optionalObjectID(c, "user_id")
+	if err != nil {
+		return err
+	}
+
+	details := map[string]string{"type": eventType, "email": email, "ip": ip}
+	if err := h.audit(c, models.AuditAdminSecurityRead, userID, details); err != nil {
+		return err
+	}
+
+	events, total, err := h.securityEvents.GetAll(c.Context(), userID, eventType, email, ip, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[pagination.Page[models.SecurityEvent]]{
+			Timestamp: time.Now(),
+			Body: pagination.Page[models.SecurityEvent]{
+				Items:     events,
+				Total:     total,
+				PageIndex: page,
+				PageSize:  pageSize,
+			},
+			Code:    fiber.StatusOK,
+			Status:  true,
+			Message: "Security events retrieved successfully",
+		},
+	)
+}
+
+// audit records the action before it runs, so nothing happens without a trace.
+func (h *adminHandler) audit(c *fiber.Ctx, action string, target *primitive.ObjectID, details map[string]string) error {
+	entry := &models.AuditLog{
+		ActorID:   middleware.UserID(c),
+		Action:    action,
+		TargetID:  target,
+		Details:   details,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+
+	return h.auditService.Record(c.Context(), entry)
+}
+
+func targetID(c *fiber.Ctx) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return primitive.NilObjectID, apperr.Wrap(apperr.KindValidation, "Id invalid", err)
+	}
+
+	return oid, nil
+}
+
+func optionalObjectID(c *fiber.Ctx, key string) (*primitive.ObjectID, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	oid, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.KindValidation, key+" invalid", err)
+	}
+
+	return &oid, nil
+}
+
+func pageParams(c *fiber.Ctx) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return page, pageSize
+}
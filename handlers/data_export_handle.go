@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"time"
+	exportdto "todolist-auth-fiber/dtos/exportDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	mappers "todolist-auth-fiber/utils/mappers/export"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DataExportHandler interface {
+	Create(c *fiber.Ctx) error
+	GetAll(c *fiber.Ctx) error
+	Get(c *fiber.Ctx) error
+	Download(c *fiber.Ctx) error
+}
+
+type dataExportHandler struct {
+	service services.DataExportService
+}
+
+func NewDataExportHandler(service services.DataExportService) DataExportHandler {
+	return &dataExportHandler{service: service}
+}
+
+func (h *dataExportHandler) Create(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	export, err := h.service.Request(c.Context(), user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[exportdto.DataExportDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.DataExportToDataExportDTO(export),
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "Data export queued, check its status to download it",
+		},
+	)
+}
+
+func (h *dataExportHandler) GetAll(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	exports, err := h.service.GetAll(c.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	dtos := []exportdto.DataExportDTO{}
+	for i := range exports {
+		dto, err := h.toDTO(user, &exports[i])
+		if err != nil {
+			return err
+		}
+		dtos = append(dtos, dto)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]exportdto.DataExportDTO]{
+			Timestamp: time.Now(),
+			Body:      dtos,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Data exports retrieved successfully",
+		},
+	)
+}
+
+func (h *dataExportHandler) Get(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	oid, errParseId := primitive.ObjectIDFromHex(c.Params("id"))
+	if errParseId != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      errParseId.Error(),
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Id invalid",
+			},
+		)
+	}
+
+	export, err := h.service.Get(c.Context(), user.ID, oid)
+	if err != nil {
+		return err
+	}
+
+	dto, err := h.toDTO(user, export)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[exportdto.DataExportDTO]{
+			Timestamp: time.Now(),
+			Body:      dto,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Data export retrieved successfully",
+		},
+	)
+}
+
+// Download is reached from the signed link, without an Authorization header.
+func (h *dataExportHandler) Download(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+
+	oid, errParseId := primitive.ObjectIDFromHex(c.Params("id"))
+	if errParseId != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      errParseId.Error(),
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Id invalid",
+			},
+		)
+	}
+
+	export, file, size, err := h.service.Open(c.Context(), c.Query("token"), oid)
+	if err != nil {
+		return err
+	}
+
+	filename := "todolist-export-" + export.CreatedAt.UTC().Format("2006-01-02") + ".zip"
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	return c.Status(fiber.StatusOK).SendStream(file, int(size))
+}
+
+// toDTO adds a fresh download link to ready exports.
+func (h *dataExportHandler) toDTO(user *models.User, export *models.DataExport) (exportdto.DataExportDTO, error) {
+	dto := mappers.DataExportToDataExportDTO(export)
+	if export.Status != models.DataExportReady || !export.ExpiresAt.After(time.Now()) {
+		return dto, nil
+	}
+
+	link, expiresAt, err := h.service.DownloadLink(user, export)
+	if err != nil {
+		return dto, err
+	}
+
+	dto.DownloadURL = link
+	dto.DownloadURLExpiresAt = &expiresAt
+
+	return dto, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"time"
+	dto "todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/user"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type EmailHandler interface {
+	RequestChange(c *fiber.Ctx) error
+	ConfirmChange(c *fiber.Ctx) error
+	CancelChange(c *fiber.Ctx) error
+}
+
+type emailHandler struct {
+	changeService  services.EmailChangeService
+	securityEvents services.SecurityEventService
+}
+
+func NewEmailHandler(changeService services.EmailChangeService, securityEvents services.SecurityEventService) EmailHandler {
+	return &emailHandler{
+		changeService:  changeService,
+		securityEvents: securityEvents,
+	}
+}
+
+func (h *emailHandler) RequestChange(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req dto.ChangeEmailDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	if err := h.changeService.Request(c.Context(), user, req.Email, req.CurrentPassword); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "Open the link sent to the new email to confirm the change",
+		},
+	)
+}
+
+func (h *emailHandler) ConfirmChange(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      "",
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Token is required",
+			},
+		)
+	}
+
+	user, err := h.changeService.Confirm(c.Context(), token)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityEmailChanged, user, "", nil)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.UserDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.UserToUserDTO(user),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Email changed with successfully",
+		},
+	)
+}
+
+func (h *emailHandler) CancelChange(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      "",
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Token is required",
+			},
+		)
+	}
+
+	if err := h.changeService.Cancel(c.Context(), token); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Email change cancelled",
+		},
+	)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"time"
+	identitydto "todolist-auth-fiber/dtos/identityDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/identity"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type IdentityHandler interface {
+	GetAll(c *fiber.Ctx) error
+	BeginLink(c *fiber.Ctx) error
+	Link(c *fiber.Ctx) error
+	Unlink(c *fiber.Ctx) error
+}
+
+type identityHandler struct {
+	service services.OIDCService
+}
+
+func NewIdentityHandler(service services.OIDCService) IdentityHandler {
+	return &identityHandler{service: service}
+}
+
+func (h *identityHandler) GetAll(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	identities, err := h.service.GetIdentities(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	dtos := []identitydto.IdentityDTO{}
+	for i := range identities {
+		dtos = append(dtos, mappers.IdentityToIdentityDTO(&identities[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]identitydto.IdentityDTO]{
+			Timestamp: time.Now(),
+			Body:      dtos,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Linked accounts retrieved successfully",
+		},
+	)
+}
+
+func (h *identityHandler) BeginLink(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	authURL, err := h.service.Begin(c.Context(), c.Params("provider"), models.OIDCPurposeLink, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[identitydto.AuthorizationURLDTO]{
+			Timestamp: time.Now(),
+			Body:      identitydto.AuthorizationURLDTO{AuthorizationURL: authURL},
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Redirect the user to the provider",
+		},
+	)
+}
+
+func (h *identityHandler) Link(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req identitydto.CallbackDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	identity, err := h.service.Link(c.Context(), user, c.Params("provider"), req.Code, req.State)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		res.ResponseHttp[identitydto.IdentityDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.IdentityToIdentityDTO(identity),
+			Code:      fiber.StatusCreated,
+			Status:    true,
+			Message:   "Account linked with successfully!",
+		},
+	)
+}
+
+func (h *identityHandler) Unlink(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	if err := h.service.Unlink(c.Context(), user, c.Params("provider")); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Account unlinked with successfully!",
+		},
+	)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"time"
+	oauthdto "todolist-auth-fiber/dtos/oauthDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/oauth"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OAuthClientHandler interface {
+	Create(c *fiber.Ctx) error
+	GetAll(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+}
+
+type oauthClientHandler struct {
+	service services.OAuthClientService
+}
+
+func NewOAuthClientHandler(service services.OAuthClientService) OAuthClientHandler {
+	return &oauthClientHandler{service: service}
+}
+
+func (h *oauthClientHandler) Create(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req oauthdto.CreateClientDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	secret, client, err := h.service.Register(c.Context(), user, req.Name, req.RedirectURIs, req.Scopes, req.Confidential)
+	if err != nil {
+		return err
+	}
+
+	message := "OAuth client registered!"
+	if secret != "" {
+		message = "OAuth client registered! Copy the client secret now, it will not be shown again"
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		res.ResponseHttp[oauthdto.CreatedClientDTO]{
+			Timestamp: time.Now(),
+			Body: oauthdto.CreatedClientDTO{
+				ClientDTO:    mappers.ClientToClientDTO(client),
+				ClientSecret: secret,
+			},
+			Code:    fiber.StatusCreated,
+			Status:  true,
+			Message: message,
+		},
+	)
+}
+
+func (h *oauthClientHandler) GetAll(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	clients, err := h.service.GetAllByOwnerId(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	dtos := []oauthdto.ClientDTO{}
+	for i := range clients {
+		dtos = append(dtos, mappers.ClientToClientDTO(&clients[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]oauthdto.ClientDTO]{
+			Timestamp: time.Now(),
+			Body:      dtos,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "OAuth clients retrieved successfully",
+		},
+	)
+}
+
+func (h *oauthClientHandler) Delete(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	if err := h.service.Delete(c.Context(), userID, c.Params("client_id")); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "OAuth client deleted with successfully!",
+		},
+	)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	oauthdto "todolist-auth-fiber/dtos/oauthDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/oauth"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OAuthHandler interface {
+	Authorize(c *fiber.Ctx) error
+	Consent(c *fiber.Ctx) error
+	Token(c *fiber.Ctx) error
+	Introspect(c *fiber.Ctx) error
+	Revoke(c *fiber.Ctx) error
+}
+
+type oauthHandler struct {
+	service       services.OAuthService
+	clientService services.OAuthClientService
+}
+
+func NewOAuthHandler(service services.OAuthService, clientService services.OAuthClientService) OAuthHandler {
+	return &oauthHandler{
+		service:       service,
+		clientService: clientService,
+	}
+}
+
+// Authorize returns what the consent screen has to show. The frontend posts the user's
+// decision back to Consent and follows the returned redirect.
+func (h *oauthHandler) Authorize(c *fiber.Ctx) error {
+	var req oauthdto.AuthorizeDTO
+	if err := c.QueryParser(&req); err != nil {
+		return apperr.Validation("Invalid query parameters")
+	}
+
+	prompt, err := h.service.Authorize(c.Context(), req)
+	if err != nil {
+		return authorizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[oauthdto.AuthorizationPromptDTO]{
+			Timestamp: time.Now(),
+			Body:      *prompt,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Authorization request is valid",
+		},
+	)
+}
+
+func (h *oauthHandler) Consent(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req oauthdto.ConsentDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	var redirectTo string
+	var err error
+	if req.Approve {
+		redirectTo, err = h.service.Approve(c.Context(), user, req.AuthorizeDTO)
+	} else {
+		redirectTo, err = h.service.Deny(c.Context(), req.AuthorizeDTO)
+	}
+	if err != nil {
+		return authorizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[oauthdto.RedirectDTO]{
+			Timestamp: time.Now(),
+			Body:      oauthdto.RedirectDTO{RedirectTo: redirectTo},
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Redirect the user to continue",
+		},
+	)
+}
+
+func (h *oauthHandler) Token(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var req oauthdto.TokenRequestDTO
+	if err := c.BodyParser(&req); err != nil {
+		return oauthError(c, oauth.NewError(fiber.StatusBadRequest, oauth.ErrInvalidRequest, "Body must be application/x-www-form-urlencoded"))
+	}
+
+	client, err := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return oauthFailure(c, err)
+	}
+
+	tokens, err := h.service.Exchange(c.Context(), client, req, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return oauthFailure(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tokens)
+}
+
+func (h *oauthHandler) Introspect(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var req oauthdto.TokenHintDTO
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return oauthError(c, oauth.NewError(fiber.StatusBadRequest, oauth.ErrInvalidRequest, "token is required"))
+	}
+
+	client, err := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return oauthFailure(c, err)
+	}
+
+	introspection, err := h.service.Introspect(c.Context(), client, req.Token)
+	if err != nil {
+		return oauthFailure(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(introspection)
+}
+
+func (h *oauthHandler) Revoke(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var req oauthdto.TokenHintDTO
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return oauthError(c, oauth.NewError(fiber.StatusBadRequest, oauth.ErrInvalidRequest, "token is required"))
+	}
+
+	client, err := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return oauthFailure(c, err)
+	}
+
+	if err := h.service.Revoke(c.Context(), client, req.Token); err != nil {
+		return oauthFailure(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// authenticateClient reads the client credentials from HTTP Basic auth, falling back to
+// the client_id and client_secret form fields (RFC 6749 section 2.3.1).
+func (h *oauthHandler) authenticateClient(c *fiber.Ctx, clientID string, clientSecret string) (*models.OAuthClient, error) {
+	header := c.Get(fiber.HeaderAuthorization)
+	if encoded, ok := strings.CutPrefix(header, "Basic "); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, oauth.NewError(401, oauth.ErrInvalidClient, "Malformed Basic credentials")
+		}
+
+		id, secret, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, oauth.NewError(401, oauth.ErrInvalidClient, "Malformed Basic credentials")
+		}
+
+		if clientID, err = url.QueryUnescape(id); err != nil {
+			return nil, oauth.NewError(401, oauth.ErrInvalidClient, "Malformed Basic credentials")
+		}
+		if clientSecret, err = url.QueryUnescape(secret); err != nil {
+			return nil, oauth.NewError(401, oauth.ErrInvalidClient, "Malformed Basic credentials")
+		}
+	}
+
+	return h.clientService.Authenticate(c.Context(), clientID, clientSecret)
+}
+
+// authorizationError answers errors that belong to the client with a redirect the frontend
+// follows, and everything else (unknown client, bad redirect_uri) to the user directly.
+func authorizationError(c *fiber.Ctx, err error) error {
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) && oauthErr.RedirectURI != "" {
+		return c.Status(fiber.StatusOK).JSON(
+			res.ResponseHttp[oauthdto.RedirectDTO]{
+				Timestamp: time.Now(),
+				Body:      oauthdto.RedirectDTO{RedirectTo: oauthErr.RedirectTo()},
+				Code:      fiber.StatusOK,
+				Status:    false,
+				Message:   oauthErr.Error(),
+			},
+		)
+	}
+
+	if oauthErr != nil {
+		return apperr.Wrap(apperr.KindValidation, oauthErr.Description, err)
+	}
+
+	return err
+}
+
+// oauthFailure answers in the RFC 6749 error format. Internal errors are logged and only
+// reported as server_error.
+func oauthFailure(c *fiber.Ctx, err error) error {
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) {
+		return oauthError(c, oauthErr)
+	}
+
+	appErr := apperr.As(err)
+	status := appErr.Kind.Status()
+	if apperr.IsInternal(err) {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+		return oauthError(c, oauth.NewError(status, oauth.ErrServerError, "Internal error"))
+	}
+
+	return oauthError(c, oauth.NewError(status, oauth.ErrInvalidRequest, appErr.Message))
+}
+
+func oauthError(c *fiber.Ctx, err *oauth.Error) error {
+	status := err.Status
+	if status == 0 {
+		status = fiber.StatusBadRequest
+	}
+
+	if err.Code == oauth.ErrInvalidClient {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+
+	return c.Status(status).JSON(err)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"time"
+	dto "todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordHandler interface {
+	Forgot(c *fiber.Ctx) error
+	Reset(c *fiber.Ctx) error
+	Change(c *fiber.Ctx) error
+}
+
+type passwordHandler struct {
+	resetService   services.PasswordResetService
+	userService    services.UserService
+	sessionService services.SessionService
+	securityEvents services.SecurityEventService
+}
+
+func NewPasswordHandler(
+	resetService services.PasswordResetService,
+	userService services.UserService,
+	sessionService services.SessionService,
+	securityEvents services.SecurityEventService,
+) PasswordHandler {
+	return &passwordHandler{
+		resetService:   resetService,
+		userService:    userService,
+		sessionService: sessionService,
+		securityEvents: securityEvents,
+	}
+}
+
+func (h *passwordHandler) Forgot(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	if err := h.resetService.Request(c.Context(), req.Email); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "If the email belongs to an account, a reset link was sent to it",
+		},
+	)
+}
+
+func (h *passwordHandler) Reset(c *fiber.Ctx) error {
+	var req dto.ResetPasswordDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	user, err := h.resetService.Confirm(c.Context(), req.Token, req.Password)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityPasswordReset, user, "", nil)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Password reset with successfully! Please login again",
+		},
+	)
+}
+
+// Change replaces the password of the authenticated user. The credentials version bump
+// logs out every session, so the caller gets a fresh session in the response.
+func (h *passwordHandler) Change(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req dto.ChangePasswordDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	updated, err := h.userService.ChangePassword(c.Context(), user, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityPasswordChanged, updated, "", nil)
+
+	if _, err := h.sessionService.RevokeAll(c.Context(), updated.ID); err != nil {
+		return err
+	}
+
+	tokens, err := h.sessionService.Start(c.Context(), updated, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[res.ResponseToken]{
+			Timestamp: time.Now(),
+			Body:      *tokens,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Password changed with successfully! Other sessions were logged out",
+		},
+	)
+}
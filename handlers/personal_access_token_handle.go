@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"time"
+	tokendto "todolist-auth-fiber/dtos/tokenDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/token"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalAccessTokenHandler interface {
+	Create(c *fiber.Ctx) error
+	GetAll(c *fiber.Ctx) error
+	Revoke(c *fiber.Ctx) error
+}
+
+type personalAccessTokenHandler struct {
+	service services.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(service services.PersonalAccessTokenService) PersonalAccessTokenHandler {
+	return &personalAccessTokenHandler{service: service}
+}
+
+func (h *personalAccessTokenHandler) Create(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	var req tokendto.CreatePersonalAccessTokenDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
+	}
+
+	expiresIn := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+
+	token, saved, err := h.service.Create(c.Context(), user, req.Name, req.Scopes, expiresIn)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		res.ResponseHttp[tokendto.CreatedPersonalAccessTokenDTO]{
+			Timestamp: time.Now(),
+			Body: tokendto.CreatedPersonalAccessTokenDTO{
+				Token:                  token,
+				PersonalAccessTokenDTO: mappers.PersonalAccessTokenToDTO(saved),
+			},
+			Code:    fiber.StatusCreated,
+			Status:  true,
+			Message: "Personal access token created! Copy it now, it will not be shown again",
+		},
+	)
+}
+
+func (h *personalAccessTokenHandler) GetAll(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	tokens, err := h.service.GetAllByUserId(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	dtos := []tokendto.PersonalAccessTokenDTO{}
+	for i := range tokens {
+		dtos = append(dtos, mappers.PersonalAccessTokenToDTO(&tokens[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]tokendto.PersonalAccessTokenDTO]{
+			Timestamp: time.Now(),
+			Body:      dtos,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Personal access tokens retrieved successfully",
+		},
+	)
+}
+
+func (h *personalAccessTokenHandler) Revoke(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	oid, errParseId := primitive.ObjectIDFromHex(c.Params("id"))
+	if errParseId != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      errParseId.Error(),
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Id invalid",
+			},
+		)
+	}
+
+	if err := h.service.Revoke(c.Context(), userID, oid); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Personal access token revoked with successfully!",
+		},
+	)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"log"
+	"time"
+	securitydto "todolist-auth-fiber/dtos/securityDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	mappers "todolist-auth-fiber/utils/mappers/security"
+	"todolist-auth-fiber/utils/pagination"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SecurityEventHandler interface {
+	GetAll(c *fiber.Ctx) error
+}
+
+type securityEventHandler struct {
+	service services.SecurityEventService
+}
+
+func NewSecurityEventHandler(service services.SecurityEventService) SecurityEventHandler {
+	return &securityEventHandler{service: service}
+}
+
+// GetAll lists the security activity of the authenticated user, newest first.
+func (h *securityEventHandler) GetAll(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+	page, pageSize := pageParams(c)
+
+	events, total, err := h.service.GetAllByUserId(c.Context(), userID, page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	dtos := []securitydto.SecurityEventDTO{}
+	for i := range events {
+		dtos = append(dtos, mappers.SecurityEventToSecurityEventDTO(&events[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[pagination.Page[securitydto.SecurityEventDTO]]{
+			Timestamp: time.Now(),
+			Body: pagination.Page[securitydto.SecurityEventDTO]{
+				Items:     dtos,
+				Total:     total,
+				PageIndex: page,
+				PageSize:  pageSize,
+			},
+			Code:    fiber.StatusOK,
+			Status:  true,
+			Message: "Security activity retrieved successfully",
+		},
+	)
+}
+
+// recordSecurityEvent never fails the request it describes: the action already happened,
+// and refusing logins while the log is unavailable would hurt more than a gap in it.
+// email is only needed when user is nil.
+func recordSecurityEvent(c *fiber.Ctx, events services.SecurityEventService, eventType string, user *models.User, email string, details map[string]string) {
+	event := &models.SecurityEvent{
+		Type:      eventType,
+		Email:     email,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Details:   details,
+	}
+
+	if user != nil {
+		event.UserID = &user.ID
+		event.Email = user.Email
+	}
+
+	if err := events.Record(c.Context(), event); err != nil {
+		log.Printf("failed to record security event %s: %v", eventType, err)
+	}
+}
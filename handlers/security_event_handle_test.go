@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeSecurityEventService struct {
+	services.SecurityEventService
+	recorded []models.SecurityEvent
+	fail     bool
+}
+
+func (s *fakeSecurityEventService) Record(ctx context.Context, event *models.SecurityEvent) error {
+	if s.fail {
+		return errors.New("security_events unavailable")
+	}
+	s.recorded = append(s.recorded, *event)
+	return nil
+}
+
+func TestRecordSecurityEvent(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com"}
+
+	tests := []struct {
+		name      string
+		user      *models.User
+		email     string
+		details   map[string]string
+		fail      bool
+		wantUser  bool
+		wantEmail string
+	}{
+		{
+			name:      "known account",
+			user:      user,
+			email:     "typed@example.com",
+			details:   map[string]string{"method": "password"},
+			wantUser:  true,
+			wantEmail: "ada@example.com",
+		},
+		{
+			name:      "unknown email",
+			email:     "nobody@example.com",
+			details:   map[string]string{"reason": "unknown_account"},
+			wantEmail: "nobody@example.com",
+		},
+		{
+			name: "log unavailable",
+			user: user,
+			fail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &fakeSecurityEventService{fail: tt.fail}
+
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				recordSecurityEvent(c, events, models.SecurityLoginSucceeded, tt.user, tt.email, tt.details)
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(fiber.MethodPost, "/", nil)
+			req.Header.Set(fiber.HeaderUserAgent, "test-agent/1.0")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// The action already happened, so a failed write never fails the request.
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			if tt.fail {
+				return
+			}
+
+			if len(events.recorded) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(events.recorded))
+			}
+			event := events.recorded[0]
+
+			if event.Type != models.SecurityLoginSucceeded || event.Email != tt.wantEmail {
+				t.Fatalf("event = %+v, want type %q and email %q", event, models.SecurityLoginSucceeded, tt.wantEmail)
+			}
+			if (event.UserID != nil) != tt.wantUser || (tt.wantUser && *event.UserID != user.ID) {
+				t.Fatalf("event user = %v, want user %v", event.UserID, tt.wantUser)
+			}
+			if event.IP == "" || event.UserAgent != "test-agent/1.0" {
+				t.Fatalf("event ip %q user agent %q, want the request's", event.IP, event.UserAgent)
+			}
+			for key, value := range tt.details {
+				if event.Details[key] != value {
+					t.Fatalf("event details = %v, want %v", event.Details, tt.details)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+	sessiondto "todolist-auth-fiber/dtos/sessionDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	mappers "todolist-auth-fiber/utils/mappers/session"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionHandler interface {
+	GetAll(c *fiber.Ctx) error
+	Revoke(c *fiber.Ctx) error
+	RevokeOthers(c *fiber.Ctx) error
+}
+
+type sessionHandler struct {
+	service        services.SessionService
+	securityEvents services.SecurityEventService
+}
+
+func NewSessionHandler(service services.SessionService, securityEvents services.SecurityEventService) SessionHandler {
+	return &sessionHandler{
+		service:        service,
+		securityEvents: securityEvents,
+	}
+}
+
+func (h *sessionHandler) GetAll(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+	currentID, _ := middleware.Claims(c).SessionObjectID()
+
+	sessions, err := h.service.GetAllByUserId(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	dtos := []sessiondto.SessionDTO{}
+	for i := range sessions {
+		dtos = append(dtos, mappers.SessionToSessionDTO(&sessions[i], currentID))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]sessiondto.SessionDTO]{
+			Timestamp: time.Now(),
+			Body:      dtos,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Sessions retrieved successfully",
+		},
+	)
+}
+
+func (h *sessionHandler) Revoke(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	oid, errParseId := primitive.ObjectIDFromHex(c.Params("id"))
+	if errParseId != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      errParseId.Error(),
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Id invalid",
+			},
+		)
+	}
+
+	if err := h.service.Revoke(c.Context(), userID, oid); err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecuritySessionRevoked, middleware.User(c), "", map[string]string{
+		"session_id": oid.Hex(),
+	})
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Session revoked with successfully!",
+		},
+	)
+}
+
+func (h *sessionHandler) RevokeOthers(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	currentID, err := middleware.Claims(c).SessionObjectID()
+	if err != nil {
+		return apperr.Validation("Current session is unknown")
+	}
+
+	revoked, err := h.service.RevokeOthers(c.Context(), userID, currentID)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecuritySessionRevoked, middleware.User(c), "", map[string]string{
+		"scope":   "others",
+		"revoked": strconv.FormatInt(revoked, 10),
+	})
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[int64]{
+			Timestamp: time.Now(),
+			Body:      revoked,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Other sessions revoked with successfully!",
+		},
+	)
+}
@@ -2,12 +2,12 @@ package handlers
 
 import (
 	"strconv"
-	"strings"
 	"time"
 	taskdto "todolist-auth-fiber/dtos/taskDto"
+	"todolist-auth-fiber/middleware"
 	"todolist-auth-fiber/models"
 	"todolist-auth-fiber/services"
-	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
 	"todolist-auth-fiber/utils/pagination"
 	"todolist-auth-fiber/utils/res"
 
@@ -38,82 +38,20 @@ func NewTaskHandler(service services.TaskService) TaskHandler {
 func (h *taskHandler) GetById(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
+	userID := middleware.UserID(c)
 
 	oid, errParseId := primitive.ObjectIDFromHex(id)
 	if errParseId != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errParseId.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Id invalid",
-			},
-		)
-
+		return apperr.Validation("Id invalid")
 	}
 
-	task, code, errGet := h.service.GetById(c.Context(), oid)
+	task, errGet := h.service.GetById(c.Context(), oid)
 	if errGet != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errGet.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the get tasks",
-			},
-		)
+		return errGet
 	}
 
 	if task.UserID != userID {
-		return c.Status(fiber.StatusForbidden).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusForbidden,
-				Status:    false,
-				Message:   "You are not authorized to see this task",
-			},
-		)
+		return apperr.Forbidden("You are not authorized to see this task")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -130,106 +68,28 @@ func (h *taskHandler) GetById(c *fiber.Ctx) error {
 func (h *taskHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
+	userID := middleware.UserID(c)
 
 	if id == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Id is required",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+		return apperr.Validation("Id is required")
 	}
 
 	oid, errParseId := primitive.ObjectIDFromHex(id)
 	if errParseId != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errParseId.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Id invalid",
-			},
-		)
-
+		return apperr.Validation("Id invalid")
 	}
 
-	task, code, errGet := h.service.GetById(c.Context(), oid)
+	task, errGet := h.service.GetById(c.Context(), oid)
 	if errGet != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errGet.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the get tasks",
-			},
-		)
+		return errGet
 	}
 
 	if task.UserID != userID {
-		return c.Status(fiber.StatusForbidden).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusForbidden,
-				Status:    false,
-				Message:   "You are not authorized to delete this task",
-			},
-		)
+		return apperr.Forbidden("You are not authorized to delete this task")
 	}
 
-	if _, err := h.service.Delete(c.Context(), oid); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusInternalServerError,
-				Status:    false,
-				Message:   "Error the to delete task",
-			},
-		)
+	if err := h.service.Delete(c.Context(), oid); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -244,57 +104,12 @@ func (h *taskHandler) Delete(c *fiber.Ctx) error {
 }
 
 func (h *taskHandler) Create(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
+	userID := middleware.UserID(c)
 
 	var req taskdto.CreateTaskDTO
 
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Inputs invalids",
-			},
-		)
+		return apperr.Validation("Invalid request body")
 	}
 
 	if err := validater.Struct(req); err != nil {
@@ -303,28 +118,12 @@ func (h *taskHandler) Create(c *fiber.Ctx) error {
 			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[[]string]{
-				Timestamp: time.Now(),
-				Body:      errors,
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Inputs invalids",
-			},
-		)
+		return apperr.Validation("Inputs invalids").WithDetails(errors)
 	}
 
-	saved, code, err := h.service.Create(c.Context(), userID, req)
+	saved, err := h.service.Create(c.Context(), userID, req)
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Inputs invalids",
-			},
-		)
+		return err
 	}
 
 	return c.Status(201).JSON(
@@ -341,107 +140,29 @@ func (h *taskHandler) Create(c *fiber.Ctx) error {
 func (h *taskHandler) ChangeStatus(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
+	userID := middleware.UserID(c)
 
 	if id == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "Id is required",
-			},
-		)
+		return apperr.Validation("Id is required")
 	}
 
 	oid, errParseId := primitive.ObjectIDFromHex(id)
 	if errParseId != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errParseId.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Id invalid",
-			},
-		)
-
+		return apperr.Validation("Id invalid")
 	}
 
-	task, code, errGet := h.service.GetById(c.Context(), oid)
+	task, errGet := h.service.GetById(c.Context(), oid)
 	if errGet != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errGet.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the get tasks",
-			},
-		)
+		return errGet
 	}
 
 	if task.UserID != userID {
-		return c.Status(fiber.StatusForbidden).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusForbidden,
-				Status:    false,
-				Message:   "You are not authorized to change status this task",
-			},
-		)
+		return apperr.Forbidden("You are not authorized to change status this task")
 	}
 
-	taskChanged, code, err := h.service.ChangeStatus(c.Context(), oid, task)
+	taskChanged, err := h.service.ChangeStatus(c.Context(), oid, task)
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[*models.Todo]{
-				Timestamp: time.Now(),
-				Body:      task,
-				Code:      code,
-				Status:    false,
-				Message:   "Error the change task status.",
-			},
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -458,71 +179,17 @@ func (h *taskHandler) ChangeStatus(c *fiber.Ctx) error {
 func (h *taskHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
-
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
-	}
+	userID := middleware.UserID(c)
 
 	oid, errParseId := primitive.ObjectIDFromHex(id)
 	if errParseId != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errParseId.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Id invalid",
-			},
-		)
-
+		return apperr.Validation("Id invalid")
 	}
 
 	var req taskdto.UpdateTaskDTO
 
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Inputs invalids",
-			},
-		)
+		return apperr.Validation("Invalid request body")
 	}
 
 	if err := validater.Struct(req); err != nil {
@@ -531,53 +198,21 @@ func (h *taskHandler) Update(c *fiber.Ctx) error {
 			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[[]string]{
-				Timestamp: time.Now(),
-				Body:      errors,
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Inputs invalids",
-			},
-		)
+		return apperr.Validation("Inputs invalids").WithDetails(errors)
 	}
 
-	task, code, errGet := h.service.GetById(c.Context(), oid)
+	task, errGet := h.service.GetById(c.Context(), oid)
 	if errGet != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errGet.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the get tasks",
-			},
-		)
+		return errGet
 	}
 
 	if task.UserID != userID {
-		return c.Status(fiber.StatusForbidden).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusForbidden,
-				Status:    false,
-				Message:   "You are not authorized to updated this task",
-			},
-		)
+		return apperr.Forbidden("You are not authorized to updated this task")
 	}
 
-	taskUpdated, code, err := h.service.Update(c.Context(), oid, req)
+	taskUpdated, err := h.service.Update(c.Context(), oid, req)
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the to update task",
-			},
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -592,44 +227,7 @@ func (h *taskHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *taskHandler) GetAll(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "Missing Authorization header",
-			},
-		)
-	}
-
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "Invalid Authorization header format",
-			},
-		)
-	}
-
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "You are not authorized",
-			},
-		)
-	}
+	userID := middleware.UserID(c)
 
 	title := c.Query("title", "")
 	doneParam := c.Query("done", "")
@@ -652,15 +250,7 @@ func (h *taskHandler) GetAll(c *fiber.Ctx) error {
 
 	tasks, total, err := h.service.GetAll(c.Context(), userID, title, done, createdAtBefore, createdAtAfter, page, pageSize)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusInternalServerError,
-				Status:    false,
-				Message:   "Error while fetching tasks",
-			},
-		)
+		return err
 	}
 
 	response := pagination.Page[models.Todo]{
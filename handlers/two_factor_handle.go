@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"time"
+	dto "todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorHandler interface {
+	Enroll(c *fiber.Ctx) error
+	Activate(c *fiber.Ctx) error
+	Disable(c *fiber.Ctx) error
+}
+
+type twoFactorHandler struct {
+	service        services.TwoFactorService
+	securityEvents services.SecurityEventService
+}
+
+func NewTwoFactorHandler(service services.TwoFactorService, securityEvents services.SecurityEventService) TwoFactorHandler {
+	return &twoFactorHandler{
+		service:        service,
+		securityEvents: securityEvents,
+	}
+}
+
+func (h *twoFactorHandler) Enroll(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	enrollment, err := h.service.Enroll(c.Context(), user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[services.TOTPEnrollment]{
+			Timestamp: time.Now(),
+			Body:      *enrollment,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Scan the uri with your authenticator app and confirm with a code",
+		},
+	)
+}
+
+func (h *twoFactorHandler) Activate(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	req, err := parseTwoFactorCode(c)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := h.service.Activate(c.Context(), user, req.Code)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityTwoFactorEnabled, user, "", nil)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]string]{
+			Timestamp: time.Now(),
+			Body:      recoveryCodes,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Two-factor authentication enabled. Store the recovery codes, they are shown only once",
+		},
+	)
+}
+
+func (h *twoFactorHandler) Disable(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	req, err := parseTwoFactorCode(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Disable(c.Context(), user, req.Code); err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityTwoFactorDisabled, user, "", nil)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Two-factor authentication disabled",
+		},
+	)
+}
+
+func parseTwoFactorCode(c *fiber.Ctx) (*dto.TwoFactorCodeDTO, error) {
+	var req dto.TwoFactorCodeDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return nil, apperr.Validation("Inputs invalids").WithDetails(errors)
+	}
+
+	return &req, nil
+}
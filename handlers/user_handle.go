@@ -1,17 +1,24 @@
 package handlers
 
 import (
-	"strings"
+	"log"
+	"strconv"
 	"time"
+	identitydto "todolist-auth-fiber/dtos/identityDto"
 	dto "todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
 	"todolist-auth-fiber/services"
 	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
 	"todolist-auth-fiber/utils/crypto"
 	mappers "todolist-auth-fiber/utils/mappers/user"
+	"todolist-auth-fiber/utils/policy"
 	"todolist-auth-fiber/utils/res"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var validaterUser = validator.New()
@@ -23,33 +30,71 @@ type UserHandler interface {
 	Delete(c *fiber.Ctx) error
 	Update(c *fiber.Ctx) error
 	Revoke(c *fiber.Ctx) error
+	Refresh(c *fiber.Ctx) error
+	VerifyEmail(c *fiber.Ctx) error
+	ResendVerification(c *fiber.Ctx) error
+	LoginTwoFactor(c *fiber.Ctx) error
+	RequestMagicLink(c *fiber.Ctx) error
+	RedeemMagicLink(c *fiber.Ctx) error
+	OIDCProviders(c *fiber.Ctx) error
+	BeginOIDCLogin(c *fiber.Ctx) error
+	OIDCLogin(c *fiber.Ctx) error
+	RequestRecovery(c *fiber.Ctx) error
+	Recover(c *fiber.Ctx) error
 }
 
 type userHandler struct {
-	service     services.UserService
-	taskService services.TaskService
+	service        services.UserService
+	sessionService services.SessionService
+	tokenService   services.TokenService
+	verification   services.EmailVerificationService
+	twoFactor      services.TwoFactorService
+	loginGuard     services.LoginGuardService
+	magicLink      services.MagicLinkService
+	oidc           services.OIDCService
+	deletion       services.AccountDeletionService
+	securityEvents services.SecurityEventService
+	silentSignup   bool
 }
 
-func NewUserHandler(service services.UserService, taskService services.TaskService) UserHandler {
+func NewUserHandler(
+	service services.UserService,
+	sessionService services.SessionService,
+	tokenService services.TokenService,
+	verification services.EmailVerificationService,
+	twoFactor services.TwoFactorService,
+	loginGuard services.LoginGuardService,
+	magicLink services.MagicLinkService,
+	oidc services.OIDCService,
+	deletion services.AccountDeletionService,
+	securityEvents services.SecurityEventService,
+	silentSignup bool,
+) UserHandler {
 	return &userHandler{
-		service:     service,
-		taskService: taskService,
+		service:        service,
+		sessionService: sessionService,
+		tokenService:   tokenService,
+		verification:   verification,
+		twoFactor:      twoFactor,
+		loginGuard:     loginGuard,
+		magicLink:      magicLink,
+		oidc:           oidc,
+		deletion:       deletion,
+		securityEvents: securityEvents,
+		silentSignup:   silentSignup,
 	}
 }
 
+// Create registers a user. The password is checked and hashed before the email is
+// looked up, and both the signup notice and the verification mail are sent in the
+// background, so a taken email and a new one answer in about the same time. In silent
+// signup mode a taken email gets the notice and the same 202 as a new account, which
+// then has to log in.
 func (h *userHandler) Create(c *fiber.Ctx) error {
 	var req dto.CreateUserDTO
 
 	if err := c.BodyParser(&req); err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusBadRequest,
-			Status:    false,
-			Message:   "Inputs inválidos",
-		}
-
-		return c.Status(fiber.StatusBadRequest).JSON(response)
+		return apperr.Validation("Invalid request body")
 	}
 
 	if err := validaterUser.Struct(req); err != nil {
@@ -69,131 +114,64 @@ func (h *userHandler) Create(c *fiber.Ctx) error {
 		)
 	}
 
-	checkEmail, code, err := h.service.ExistsByEmail(c.Context(), req.Email)
-	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      code,
-			Status:    false,
-			Message:   "Error the check if email already exists!",
-		}
-
-		return c.Status(code).JSON(res)
+	if violations := policy.CheckPassword(req.Password, req.Username, req.Email); violations != nil {
+		return policy.Rejected(violations)
 	}
 
-	if checkEmail == true {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusConflict,
-			Status:    false,
-			Message:   "Email already exists",
-		}
-
-		return c.Status(fiber.StatusConflict).JSON(res)
+	password, err := crypto.Encoder(req.Password)
+	if err != nil {
+		return err
 	}
 
-	checkUserName, code, err := h.service.ExistsByUserName(c.Context(), req.Username)
+	checkEmail, err := h.service.ExistsByEmail(c.Context(), req.Email)
 	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      code,
-			Status:    false,
-			Message:   "Error the check if username exists!",
-		}
-
-		return c.Status(code).JSON(res)
+		return err
 	}
 
-	if checkUserName == true {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusConflict,
-			Status:    false,
-			Message:   "Username already exists",
+	if checkEmail == true {
+		if h.silentSignup {
+			if err := h.service.NotifyExistingAccount(c.Context(), req.Email); err != nil {
+				log.Printf("failed to notify existing account of signup attempt: %v", err)
+			}
+
+			return signupAccepted(c)
 		}
 
-		return c.Status(fiber.StatusConflict).JSON(res)
+		return apperr.Conflict("Email already in use")
 	}
 
-	password, err := crypto.Encoder(req.Password)
+	checkUserName, err := h.service.ExistsByUserName(c.Context(), req.Username)
 	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error the encoder password!",
-		}
+		return err
+	}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
+	if checkUserName == true {
+		return apperr.Conflict("Username already in use")
 	}
 
 	req.Password = password
 
-	saved, code, err := h.service.Save(c.Context(), req)
+	saved, err := h.service.Save(c.Context(), req)
 	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      code,
-			Status:    false,
-			Message:   "Error the save new user! Please try again later",
-		}
-
-		return c.Status(code).JSON(res)
-	}
-
-	token, err := utils.GenerateAccessToken(saved)
-	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error in server! Please try again later",
-		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
+		return err
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(saved)
-	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error in server! Please try again later",
-		}
+	recordSecurityEvent(c, h.securityEvents, models.SecurityAccountCreated, saved, "", nil)
 
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
-	}
+	h.verification.SendAsync(saved)
 
-	_, code, errRefreshToken := h.service.SetRefreshToken(c.Context(), saved, refreshToken)
-	if errRefreshToken != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      errRefreshToken.Error(),
-				Code:      code,
-				Status:    true,
-				Message:   "Error internal in server! Please try again later",
-			},
-		)
+	if h.silentSignup {
+		return signupAccepted(c)
 	}
 
-	tokens := res.ResponseToken{
-		Token:        token,
-		RefreshToken: refreshToken,
+	tokens, err := h.sessionService.Start(c.Context(), saved, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return err
 	}
 
 	res := res.ResponseHttp[res.ResponseToken]{
 		Timestamp: time.Now(),
-		Body:      tokens,
+		Body:      *tokens,
 		Code:      fiber.StatusCreated,
 		Status:    true,
 		Message:   "Welcome",
@@ -202,19 +180,23 @@ func (h *userHandler) Create(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(res)
 }
 
+func signupAccepted(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "Check your email to continue",
+		},
+	)
+}
+
 func (h *userHandler) Login(c *fiber.Ctx) error {
 	var req dto.LoginUserDTO
 
 	if err := c.BodyParser(&req); err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusBadRequest,
-			Status:    false,
-			Message:   "Inputs invalid",
-		}
-
-		return c.Status(fiber.StatusBadRequest).JSON(response)
+		return apperr.Validation("Invalid request body")
 	}
 
 	if err := validaterUser.Struct(req); err != nil {
@@ -234,293 +216,449 @@ func (h *userHandler) Login(c *fiber.Ctx) error {
 		)
 	}
 
-	user, code, err := h.service.GetByEmail(c.Context(), req.Email)
+	lockedUntil, err := h.loginGuard.Check(c.Context(), req.Email, c.IP())
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Login invalid",
-			},
-		)
+		return h.loginRefused(c, lockedUntil, err)
 	}
 
-	check := crypto.Compare(req.Password, user.Password)
-	if check == false {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Login invalid",
-		}
+	// An unknown email and a wrong password answer alike, after the same amount of
+	// hashing, so the login cannot be used to find out which emails have an account.
+	user, err := h.service.GetByEmail(c.Context(), req.Email)
+	if err != nil && !apperr.Is(err, apperr.KindNotFound) {
+		return err
+	}
+
+	if user == nil {
+		crypto.CompareDummy(req.Password)
+		h.registerLoginFailure(c, nil, req.Email, "unknown_account")
+		return loginInvalid()
+	}
+
+	if user.Password == "" {
+		crypto.CompareDummy(req.Password)
+		h.registerLoginFailure(c, user, req.Email, "invalid_password")
+		return loginInvalid()
+	}
+
+	if !crypto.Compare(req.Password, user.Password) {
+		h.registerLoginFailure(c, user, req.Email, "invalid_password")
+		return loginInvalid()
+	}
+
+	if err := h.service.RehashPassword(c.Context(), user, req.Password); err != nil {
+		log.Printf("failed to rehash password of user %s: %v", user.ID.Hex(), err)
+	}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+	if err := h.loginGuard.RegisterSuccess(c.Context(), req.Email); err != nil {
+		return err
 	}
 
-	token, err := utils.GenerateAccessToken(user)
+	return h.startLogin(c, user, "password")
+}
+
+func loginInvalid() error {
+	return apperr.Unauthorized("Login invalid")
+}
+
+func (h *userHandler) Me(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	user, err := h.service.GetById(c.Context(), userID)
 	if err != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error in server! Please try again later",
-		}
+		return err
+	}
+
+	userDto := mappers.UserToUserDTO(user)
 
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
+	response := res.ResponseHttp[dto.UserDTO]{
+		Timestamp: time.Now(),
+		Body:      userDto,
+		Code:      fiber.StatusOK,
+		Status:    true,
+		Message:   "Me",
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// Delete schedules the account for deletion. It can be recovered until the purge date.
+func (h *userHandler) Delete(c *fiber.Ctx) error {
+	user := middleware.User(c)
+
+	pending, err := h.deletion.Request(c.Context(), user)
 	if err != nil {
-		res := res.ResponseHttp[string]{
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityAccountDeletionRequested, user, "", map[string]string{
+		"purge_at": pending.PurgeAt.UTC().Format(time.RFC3339),
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[dto.AccountDeletionDTO]{
 			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error in server! Please try again later",
+			Body:      dto.AccountDeletionDTO{PurgeAt: pending.PurgeAt},
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "Bye Bye! Your account will be deleted at purge_at, follow the link sent by email to keep it",
+		},
+	)
+}
+
+func (h *userHandler) Update(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	var req dto.UpdateUserDTO
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
 	}
 
-	tokens := res.ResponseToken{
-		Token:        token,
-		RefreshToken: refreshToken,
+	user, err := h.service.GetById(c.Context(), userID)
+	if err != nil {
+		return err
 	}
 
-	_, code, errRefreshToken := h.service.SetRefreshToken(c.Context(), user, refreshToken)
-	if errRefreshToken != nil {
-		res := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      errRefreshToken.Error(),
-			Code:      code,
-			Status:    true,
-			Message:   "Error the set refresh token",
+	if req.Username != user.Username {
+		checkUserName, err := h.service.ExistsByUserName(c.Context(), req.Username)
+		if err != nil {
+			return err
 		}
 
-		return c.Status(code).JSON(res)
+		if checkUserName == true {
+			return apperr.Conflict("Username already in use")
+		}
 	}
 
+	userUpdated, err := h.service.Update(c.Context(), user, req)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityAccountUpdated, userUpdated, "", nil)
+
+	userDto := mappers.UserToUserDTO(userUpdated)
+
 	return c.Status(fiber.StatusOK).JSON(
-		res.ResponseHttp[res.ResponseToken]{
+		res.ResponseHttp[dto.UserDTO]{
 			Timestamp: time.Now(),
-			Body:      tokens,
-			Code:      fiber.StatusCreated,
+			Body:      userDto,
+			Code:      fiber.StatusOK,
 			Status:    true,
-			Message:   "Welcome again",
+			Message:   "User updated with successfully",
 		},
 	)
 }
 
-func (h *userHandler) Me(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "",
-		}
+func (h *userHandler) Revoke(c *fiber.Ctx) error {
+	userID := middleware.UserID(c)
+
+	if _, err := h.sessionService.RevokeAll(c.Context(), userID); err != nil {
+		return err
+	}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+	if err := h.tokenService.Revoke(c.Context(), middleware.Claims(c)); err != nil {
+		return err
 	}
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		response := res.ResponseHttp[string]{
+	recordSecurityEvent(c, h.securityEvents, models.SecurityTokensRevoked, middleware.User(c), "", nil)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[string]{
 			Timestamp: time.Now(),
 			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "See you later",
+		},
+	)
+}
+
+func (h *userHandler) Refresh(c *fiber.Ctx) error {
+	var req dto.RefreshTokenDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
 	}
 
-	userID, err := utils.ExtractUserID(tokenString)
+	claims, user, err := h.tokenService.Validate(c.Context(), req.RefreshToken, utils.RefreshTokenType)
 	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
-		}
+		return err
+	}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+	sessionID, err := claims.SessionObjectID()
+	if err != nil {
+		return apperr.Wrap(apperr.KindUnauthorized, "Refresh token invalid", err)
 	}
 
-	user, code, err := h.service.GetById(c.Context(), userID)
+	tokens, err := h.sessionService.Refresh(c.Context(), user, sessionID, "", req.RefreshToken)
 	if err != nil {
-		return c.Status(code).JSON(
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[res.ResponseToken]{
+			Timestamp: time.Now(),
+			Body:      *tokens,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Tokens refreshed",
+		},
+	)
+}
+
+func (h *userHandler) VerifyEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(
 			res.ResponseHttp[string]{
 				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
+				Body:      "",
+				Code:      fiber.StatusBadRequest,
 				Status:    false,
-				Message:   "You are not Authorization",
+				Message:   "Token is required",
 			},
 		)
 	}
 
-	userDto := mappers.UserToUserDTO(user)
-
-	response := res.ResponseHttp[dto.UserDTO]{
-		Timestamp: time.Now(),
-		Body:      userDto,
-		Code:      fiber.StatusOK,
-		Status:    true,
-		Message:   "Me",
+	user, err := h.verification.Verify(c.Context(), token)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(response)
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[dto.UserDTO]{
+			Timestamp: time.Now(),
+			Body:      mappers.UserToUserDTO(user),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Email verified with successfully",
+		},
+	)
 }
 
-func (h *userHandler) Delete(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not authorized",
-		}
+func (h *userHandler) ResendVerification(c *fiber.Ctx) error {
+	user := middleware.User(c)
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+	if err := h.verification.Send(c.Context(), user); err != nil {
+		return err
 	}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	if token == authHeader {
-		response := res.ResponseHttp[string]{
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
 			Timestamp: time.Now(),
 			Body:      "",
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "Invalid Authorization header format",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "Verification email sent",
+		},
+	)
+}
+
+func (h *userHandler) LoginTwoFactor(c *fiber.Ctx) error {
+	var req dto.LoginTwoFactorDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
+				Timestamp: time.Now(),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
+				Status:    false,
+				Message:   "Inputs invalids",
+			},
+		)
 	}
 
-	userID, err := utils.ExtractUserID(token)
+	claims, user, err := h.tokenService.Validate(c.Context(), req.MfaToken, utils.MfaPendingTokenType)
 	if err != nil {
-		response := res.ResponseHttp[string]{
-			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusUnauthorized,
-			Status:    false,
-			Message:   "You are not Authorization",
+		return err
+	}
+
+	lockedUntil, err := h.loginGuard.Check(c.Context(), user.Email, c.IP())
+	if err != nil {
+		return h.loginRefused(c, lockedUntil, err)
+	}
+
+	if err := h.twoFactor.Verify(c.Context(), user, req.Code); err != nil {
+		if apperr.Is(err, apperr.KindUnauthorized) {
+			h.registerLoginFailure(c, user, user.Email, "invalid_second_factor")
 		}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(response)
+		return err
 	}
 
-	user, code, err := h.service.GetById(c.Context(), userID)
+	if err := h.tokenService.Revoke(c.Context(), claims); err != nil {
+		return err
+	}
+
+	tokens, err := h.sessionService.Start(c.Context(), user, c.Get(fiber.HeaderUserAgent), c.IP())
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "You are not Authorization",
-			},
-		)
+		return err
 	}
 
-	if code, err := h.service.Delete(c.Context(), user); err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "Error the delete the user",
-			},
-		)
+	recordSecurityEvent(c, h.securityEvents, models.SecurityLoginSucceeded, user, "", map[string]string{"method": "totp"})
+
+	tokens.Notices = h.loginNotices(c, user)
+
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[res.ResponseToken]{
+			Timestamp: time.Now(),
+			Body:      *tokens,
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Welcome again",
+		},
+	)
+}
+
+func (h *userHandler) RequestMagicLink(c *fiber.Ctx) error {
+	var req dto.MagicLinkRequestDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
 	}
 
-	if _, err := h.taskService.DeleteAllByUserId(c.Context(), userID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			res.ResponseHttp[string]{
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
 				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusInternalServerError,
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
 				Status:    false,
-				Message:   "Error the delete all task of user",
+				Message:   "Inputs invalids",
 			},
 		)
 	}
 
-	response := res.ResponseHttp[string]{
-		Timestamp: time.Now(),
-		Body:      "",
-		Code:      fiber.StatusOK,
-		Status:    false,
-		Message:   "Bye Bye",
+	if err := h.magicLink.Request(c.Context(), req.Email); err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(response)
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
+			Timestamp: time.Now(),
+			Body:      "",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "If the email belongs to an account, a sign-in link was sent to it",
+		},
+	)
 }
 
-func (h *userHandler) Update(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "",
-			},
-		)
+func (h *userHandler) RedeemMagicLink(c *fiber.Ctx) error {
+	var req dto.MagicLinkRedeemDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
 	}
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
+
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
 				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
 				Status:    false,
-				Message:   "Invalid Authorization header format",
+				Message:   "Inputs invalids",
 			},
 		)
+	}
 
+	user, err := h.magicLink.Redeem(c.Context(), req.Token)
+	if err != nil {
+		return err
 	}
 
-	userID, err := utils.ExtractUserID(tokenString)
+	return h.startLogin(c, user, "magic_link")
+}
+
+func (h *userHandler) OIDCProviders(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[[]string]{
+			Timestamp: time.Now(),
+			Body:      h.oidc.Providers(),
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Sign-in providers retrieved successfully",
+		},
+	)
+}
+
+func (h *userHandler) BeginOIDCLogin(c *fiber.Ctx) error {
+	authURL, err := h.oidc.Begin(c.Context(), c.Params("provider"), models.OIDCPurposeLogin, primitive.NilObjectID)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "You are not Authorization",
-			},
-		)
+		return err
 	}
 
-	var req dto.UpdateUserDTO
+	return c.Status(fiber.StatusOK).JSON(
+		res.ResponseHttp[identitydto.AuthorizationURLDTO]{
+			Timestamp: time.Now(),
+			Body:      identitydto.AuthorizationURLDTO{AuthorizationURL: authURL},
+			Code:      fiber.StatusOK,
+			Status:    true,
+			Message:   "Redirect the user to the provider",
+		},
+	)
+}
+
+func (h *userHandler) OIDCLogin(c *fiber.Ctx) error {
+	var req identitydto.CallbackDTO
+
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusBadRequest,
-				Status:    false,
-				Message:   "Inputs invalds",
-			},
-		)
+		return apperr.Validation("Invalid request body")
 	}
 
 	if err := validaterUser.Struct(req); err != nil {
@@ -540,161 +678,195 @@ func (h *userHandler) Update(c *fiber.Ctx) error {
 		)
 	}
 
-	user, code, err := h.service.GetById(c.Context(), userID)
+	user, err := h.oidc.Login(c.Context(), c.Params("provider"), req.Code, req.State)
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    false,
-				Message:   "You are not Authorization",
-			},
-		)
+		return err
 	}
 
-	if req.Username != user.Username {
-		checkUserName, code, err := h.service.ExistsByUserName(c.Context(), req.Username)
-		if err != nil {
-			return c.Status(code).JSON(
-				res.ResponseHttp[string]{
-					Timestamp: time.Now(),
-					Body:      err.Error(),
-					Code:      code,
-					Status:    false,
-					Message:   "Error the check if username exists!",
-				},
-			)
+	return h.startLogin(c, user, "oidc:"+c.Params("provider"))
+}
+
+func (h *userHandler) RequestRecovery(c *fiber.Ctx) error {
+	var req dto.RequestRecoveryDTO
+
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
+	}
+
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
 		}
 
-		if checkUserName == true {
-			res := res.ResponseHttp[string]{
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
 				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusConflict,
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
 				Status:    false,
-				Message:   "Username already exists",
-			}
+				Message:   "Inputs invalids",
+			},
+		)
+	}
 
-			return c.Status(fiber.StatusConflict).JSON(res)
-		}
+	if err := h.deletion.RequestRecovery(c.Context(), req.Email); err != nil {
+		return err
 	}
 
-	newPasswordHash, err := crypto.Encoder(req.Password)
-	if err != nil {
-		res := res.ResponseHttp[string]{
+	return c.Status(fiber.StatusAccepted).JSON(
+		res.ResponseHttp[string]{
 			Timestamp: time.Now(),
-			Body:      err.Error(),
-			Code:      fiber.StatusInternalServerError,
-			Status:    false,
-			Message:   "Error the encoder password!",
-		}
+			Body:      "",
+			Code:      fiber.StatusAccepted,
+			Status:    true,
+			Message:   "If the email belongs to an account pending deletion, a recovery link was sent to it",
+		},
+	)
+}
+
+func (h *userHandler) Recover(c *fiber.Ctx) error {
+	var req dto.RecoverAccountDTO
 
-		return c.Status(fiber.StatusInternalServerError).JSON(res)
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.Validation("Invalid request body")
 	}
 
-	req.Password = newPasswordHash
+	if err := validaterUser.Struct(req); err != nil {
+		errors := []string{}
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Field()+" failed on "+err.Tag())
+		}
 
-	userUpdated, codeUpdate, err := h.service.Update(c.Context(), user, req)
-	if err != nil {
-		return c.Status(int(codeUpdate)).JSON(
-			res.ResponseHttp[string]{
+		return c.Status(fiber.StatusBadRequest).JSON(
+			res.ResponseHttp[[]string]{
 				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      int(codeUpdate),
+				Body:      errors,
+				Code:      fiber.StatusBadRequest,
 				Status:    false,
-				Message:   "Error the update user",
+				Message:   "Inputs invalids",
 			},
 		)
 	}
 
-	userDto := mappers.UserToUserDTO(userUpdated)
+	user, err := h.deletion.Recover(c.Context(), req.Token)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(c, h.securityEvents, models.SecurityAccountRecovered, user, "", nil)
 
 	return c.Status(fiber.StatusOK).JSON(
 		res.ResponseHttp[dto.UserDTO]{
 			Timestamp: time.Now(),
-			Body:      userDto,
+			Body:      mappers.UserToUserDTO(user),
 			Code:      fiber.StatusOK,
 			Status:    true,
-			Message:   "User updated with successfully",
+			Message:   "Welcome back! Your account was recovered, please log in again",
 		},
 	)
 }
 
-func (h *userHandler) Revoke(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
-				Status:    false,
-				Message:   "",
-			},
-		)
-	}
+// startLogin finishes a login once the first factor succeeded: it either asks for the
+// second factor or starts a new session. method names the first factor in security events.
+func (h *userHandler) startLogin(c *fiber.Ctx, user *models.User, method string) error {
+	if user.Disabled {
+		recordSecurityEvent(c, h.securityEvents, models.SecurityLoginFailed, user, "", map[string]string{
+			"method": method,
+			"reason": "account_disabled",
+		})
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		return c.Status(fiber.StatusUnauthorized).JSON(
+		return c.Status(fiber.StatusForbidden).JSON(
 			res.ResponseHttp[string]{
 				Timestamp: time.Now(),
-				Body:      "",
-				Code:      fiber.StatusUnauthorized,
+				Body:      "account_disabled",
+				Code:      fiber.StatusForbidden,
 				Status:    false,
-				Message:   "Invalid Authorization header format",
+				Message:   "This account is disabled",
 			},
 		)
-
 	}
 
-	userID, err := utils.ExtractUserID(tokenString)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(
+	if user.PendingDeletion() {
+		recordSecurityEvent(c, h.securityEvents, models.SecurityLoginFailed, user, "", map[string]string{
+			"method": method,
+			"reason": "account_pending_deletion",
+		})
+
+		return c.Status(fiber.StatusForbidden).JSON(
 			res.ResponseHttp[string]{
 				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      fiber.StatusUnauthorized,
+				Body:      "account_pending_deletion",
+				Code:      fiber.StatusForbidden,
 				Status:    false,
-				Message:   "You are not Authorization",
+				Message:   "This account is scheduled for deletion. Recover it with the link sent by email",
 			},
 		)
 	}
 
-	user, codeGet, err := h.service.GetById(c.Context(), userID)
-	if err != nil {
-		return c.Status(codeGet).JSON(
-			res.ResponseHttp[string]{
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateToken(user, primitive.NilObjectID, utils.MfaPendingTokenType, utils.MfaPendingTokenExpiration)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(
+			res.ResponseHttp[res.ResponseMfa]{
 				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      codeGet,
-				Status:    false,
-				Message:   "You are not Authorization",
+				Body: res.ResponseMfa{
+					MfaRequired: true,
+					MfaToken:    mfaToken,
+				},
+				Code:    fiber.StatusAccepted,
+				Status:  true,
+				Message: "Two-factor authentication required",
 			},
 		)
 	}
 
-	_, code, err := h.service.SetRefreshToken(c.Context(), user, "")
+	tokens, err := h.sessionService.Start(c.Context(), user, c.Get(fiber.HeaderUserAgent), c.IP())
 	if err != nil {
-		return c.Status(code).JSON(
-			res.ResponseHttp[string]{
-				Timestamp: time.Now(),
-				Body:      err.Error(),
-				Code:      code,
-				Status:    true,
-				Message:   "Error internal in server! Please try again later",
-			},
-		)
+		return err
 	}
 
+	recordSecurityEvent(c, h.securityEvents, models.SecurityLoginSucceeded, user, "", map[string]string{"method": method})
+
+	tokens.Notices = h.loginNotices(c, user)
+
 	return c.Status(fiber.StatusOK).JSON(
-		res.ResponseHttp[string]{
+		res.ResponseHttp[res.ResponseToken]{
 			Timestamp: time.Now(),
-			Body:      "",
-			Code:      fiber.StatusOK,
+			Body:      *tokens,
+			Code:      fiber.StatusCreated,
 			Status:    true,
-			Message:   "See you later",
+			Message:   "Welcome again",
 		},
 	)
 }
+
+func (h *userHandler) loginRefused(c *fiber.Ctx, lockedUntil *time.Time, err error) error {
+	if lockedUntil != nil {
+		retryAfter := int(time.Until(*lockedUntil).Seconds()) + 1
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	}
+
+	return err
+}
+
+func (h *userHandler) registerLoginFailure(c *fiber.Ctx, user *models.User, email string, reason string) {
+	recordSecurityEvent(c, h.securityEvents, models.SecurityLoginFailed, user, email, map[string]string{"reason": reason})
+
+	if err := h.loginGuard.RegisterFailure(c.Context(), user, email, c.IP()); err != nil {
+		log.Printf("failed to register login failure: %v", err)
+	}
+}
+
+func (h *userHandler) loginNotices(c *fiber.Ctx, user *models.User) []string {
+	notices, err := h.loginGuard.Notices(c.Context(), user.ID)
+	if err != nil {
+		log.Printf("failed to load login notices for user %s: %v", user.ID.Hex(), err)
+		return nil
+	}
+
+	return notices
+}
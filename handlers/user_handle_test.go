@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeLoginUserService struct {
+	services.UserService
+	users map[string]*models.User
+}
+
+func (s *fakeLoginUserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, ok := s.users[email]
+	if !ok {
+		return nil, apperr.NotFound("User not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+type fakeLoginGuardService struct {
+	services.LoginGuardService
+	lockedUntil *time.Time
+	failures    []string
+}
+
+func (s *fakeLoginGuardService) Check(ctx context.Context, email string, ip string) (*time.Time, error) {
+	if s.lockedUntil != nil {
+		return s.lockedUntil, apperr.TooManyRequests("Too many failed login attempts, try again later")
+	}
+	return nil, nil
+}
+
+func (s *fakeLoginGuardService) RegisterFailure(ctx context.Context, user *models.User, email string, ip string) error {
+	s.failures = append(s.failures, email)
+	return nil
+}
+
+type loginResponse struct {
+	Body    interface{} `json:"body"`
+	Code    int         `json:"code"`
+	Status  bool        `json:"status"`
+	Message string      `json:"message"`
+}
+
+func TestLoginFailuresAnswerAlike(t *testing.T) {
+	hash, err := crypto.Encoder("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := &fakeLoginUserService{users: map[string]*models.User{
+		"ada@example.com":   {ID: primitive.NewObjectID(), Email: "ada@example.com", Password: hash},
+		"grace@example.com": {ID: primitive.NewObjectID(), Email: "grace@example.com"},
+	}}
+
+	tests := []struct {
+		name       string
+		email      string
+		password   string
+		wantReason string
+	}{
+		{name: "unknown email", email: "nobody@example.com", password: "correct horse battery staple", wantReason: "unknown_account"},
+		{name: "wrong password", email: "ada@example.com", password: "wrong horse battery staple", wantReason: "invalid_password"},
+		{name: "account without a password", email: "grace@example.com", password: "correct horse battery staple", wantReason: "invalid_password"},
+	}
+
+	login := func(t *testing.T, guard *fakeLoginGuardService, events *fakeSecurityEventService, email, password string) (*loginResponse, string) {
+		t.Helper()
+
+		handler := NewUserHandler(users, nil, nil, nil, nil, guard, nil, nil, nil, events, false)
+		app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+		app.Post("/login", handler.Login)
+
+		req := httptest.NewRequest(fiber.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`","password":"`+password+`"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body loginResponse
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("response is not JSON: %s", raw)
+		}
+		if body.Code != resp.StatusCode {
+			t.Fatalf("envelope code %d, status %d", body.Code, resp.StatusCode)
+		}
+		return &body, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	var first *loginResponse
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &fakeLoginGuardService{}
+			events := &fakeSecurityEventService{}
+
+			body, _ := login(t, guard, events, tt.email, tt.password)
+			if body.Code != fiber.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", body.Code)
+			}
+			if first == nil {
+				first = body
+			} else if *body != *first {
+				t.Fatalf("response = %+v, want the same as for %q: %+v", body, tests[0].name, first)
+			}
+
+			if len(guard.failures) != 1 || guard.failures[0] != tt.email {
+				t.Fatalf("registered failures = %v, want one for %q", guard.failures, tt.email)
+			}
+			if len(events.recorded) != 1 || events.recorded[0].Type != models.SecurityLoginFailed || events.recorded[0].Details["reason"] != tt.wantReason {
+				t.Fatalf("security events = %+v, want one login failure with reason %q", events.recorded, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("locked out", func(t *testing.T) {
+		lockedUntil := time.Now().Add(time.Minute)
+
+		var locked *loginResponse
+		for _, email := range []string{"nobody@example.com", "ada@example.com"} {
+			guard := &fakeLoginGuardService{lockedUntil: &lockedUntil}
+			events := &fakeSecurityEventService{}
+
+			// Even the right password is refused while the lock lasts.
+			body, retryAfter := login(t, guard, events, email, "correct horse battery staple")
+			if body.Code != fiber.StatusTooManyRequests || retryAfter == "" {
+				t.Fatalf("%s: status = %d, Retry-After %q, want 429 with Retry-After", email, body.Code, retryAfter)
+			}
+			if locked == nil {
+				locked = body
+			} else if *body != *locked {
+				t.Fatalf("%s: response = %+v, want the same as for an unknown email: %+v", email, body, locked)
+			}
+			if len(guard.failures) != 0 || len(events.recorded) != 0 {
+				t.Fatalf("%s: a locked out attempt was counted", email)
+			}
+		}
+	})
+}
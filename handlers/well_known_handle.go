@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"todolist-auth-fiber/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WellKnownHandler interface {
+	JWKS(c *fiber.Ctx) error
+}
+
+type wellKnownHandler struct{}
+
+func NewWellKnownHandler() WellKnownHandler {
+	return &wellKnownHandler{}
+}
+
+func (h *wellKnownHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.Status(fiber.StatusOK).JSON(utils.JWKS())
+}
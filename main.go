@@ -1,32 +1,305 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
 	"todolist-auth-fiber/config"
 	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
 	repository "todolist-auth-fiber/repositories"
 	"todolist-auth-fiber/routers"
 	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+	"todolist-auth-fiber/utils/oidc"
+	"todolist-auth-fiber/utils/policy"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func main() {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
 
 	config.ConnectDB()
 	db := config.GetDB()
 	// app.Use(middleware.Cors())
-	
+
+	keySet, err := utils.KeysFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	utils.ConfigureKeys(keySet)
+
+	crypto.Configure(crypto.Argon2Params{
+		Memory:      uint32(config.GetEnvInt("ARGON2_MEMORY_KIB", int(crypto.DefaultArgon2Params.Memory))),
+		Iterations:  uint32(config.GetEnvInt("ARGON2_ITERATIONS", int(crypto.DefaultArgon2Params.Iterations))),
+		Parallelism: uint8(config.GetEnvInt("ARGON2_PARALLELISM", int(crypto.DefaultArgon2Params.Parallelism))),
+		SaltLength:  crypto.DefaultArgon2Params.SaltLength,
+		KeyLength:   crypto.DefaultArgon2Params.KeyLength,
+	})
+
+	passwordPolicy := policy.PasswordPolicy{
+		MinLength:  config.GetEnvInt("PASSWORD_MIN_LENGTH", policy.DefaultPasswordPolicy.MinLength),
+		MaxLength:  config.GetEnvInt("PASSWORD_MAX_LENGTH", policy.DefaultPasswordPolicy.MaxLength),
+		MinClasses: config.GetEnvInt("PASSWORD_MIN_CLASSES", policy.DefaultPasswordPolicy.MinClasses),
+	}
+	if path := os.Getenv("PASSWORD_BLOCKLIST_FILE"); path != "" {
+		blocklist, err := policy.LoadBlocklist(path)
+		if err != nil {
+			log.Fatalf("Failed to load password blocklist: %v", err)
+		}
+		passwordPolicy.Blocklist = blocklist
+	}
+	policy.Configure(passwordPolicy)
+
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
 	taskRepository := repository.NewTaskRepository(db)
 	taskService := services.NewTaskService(taskRepository)
 	taskHandler := handlers.NewTaskHandler(taskService)
 
+	securityEventRepository := repository.NewSecurityEventRepository(db)
+	securityEventService := services.NewSecurityEventService(securityEventRepository)
+	securityEventHandler := handlers.NewSecurityEventHandler(securityEventService)
+
+	revokedTokenRepository := repository.NewRevokedTokenRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	sessionService := services.NewSessionService(sessionRepository, revokedTokenRepository)
+	sessionHandler := handlers.NewSessionHandler(sessionService, securityEventService)
+
+	actionTokenRepository := repository.NewActionTokenRepository(db)
 	userRepository := repository.NewUserRepository(db)
-	userService := services.NewUserService(userRepository)
-	userHandler := handlers.NewUserHandler(userService, taskService)
+	userService := services.NewUserService(userRepository, actionTokenRepository, mail, frontendURL())
+	tokenService := services.NewTokenService(userRepository, revokedTokenRepository)
+	emailVerificationService := services.NewEmailVerificationService(userRepository, tokenService, mail, os.Getenv("APP_BASE_URL"))
+	twoFactorService := services.NewTwoFactorService(userRepository, os.Getenv("TOTP_ISSUER"))
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, securityEventService)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(db)
+	lockoutEventRepository := repository.NewLockoutEventRepository(db)
+	loginGuardService := services.NewLoginGuardService(loginAttemptRepository, lockoutEventRepository, services.LoginGuardConfig{
+		AccountThreshold: config.GetEnvInt("LOGIN_ACCOUNT_THRESHOLD", 5),
+		IPThreshold:      config.GetEnvInt("LOGIN_IP_THRESHOLD", 20),
+		BaseLockout:      config.GetEnvDuration("LOGIN_LOCKOUT_BASE", 30*time.Second),
+		MaxLockout:       config.GetEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+		Window:           config.GetEnvDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+	})
+
+	magicLinkService := services.NewMagicLinkService(userRepository, actionTokenRepository, loginAttemptRepository, mail, frontendURL(), services.MagicLinkConfig{
+		MaxRequests: config.GetEnvInt("MAGIC_LINK_MAX_REQUESTS", 3),
+		Window:      config.GetEnvDuration("MAGIC_LINK_WINDOW", time.Hour),
+	})
+
+	userIdentityRepository := repository.NewUserIdentityRepository(db)
+	oidcRequestRepository := repository.NewOIDCRequestRepository(db)
+	oidcService := services.NewOIDCService(oidcProviders(), oidcRequestRepository, userIdentityRepository, userRepository)
+	identityHandler := handlers.NewIdentityHandler(oidcService)
+
+	accountDeletionService := services.NewAccountDeletionService(
+		userRepository,
+		actionTokenRepository,
+		sessionService,
+		mail,
+		frontendURL(),
+		config.GetEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour),
+	)
+
+	userHandler := handlers.NewUserHandler(
+		userService,
+		sessionService,
+		tokenService,
+		emailVerificationService,
+		twoFactorService,
+		loginGuardService,
+		magicLinkService,
+		oidcService,
+		accountDeletionService,
+		securityEventService,
+		os.Getenv("SILENT_SIGNUP") == "true",
+	)
+
+	passwordResetService := services.NewPasswordResetService(userRepository, actionTokenRepository, sessionService, mail, frontendURL())
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService, userService, sessionService, securityEventService)
+	emailChangeService := services.NewEmailChangeService(userRepository, actionTokenRepository, mail, os.Getenv("APP_BASE_URL"))
+	emailHandler := handlers.NewEmailHandler(emailChangeService, securityEventService)
+
+	personalAccessTokenRepository := repository.NewPersonalAccessTokenRepository(db)
+	personalAccessTokenService := services.NewPersonalAccessTokenService(personalAccessTokenRepository, userRepository)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(personalAccessTokenService)
+
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := services.NewAuditService(auditLogRepository)
+	adminService := services.NewAdminService(userRepository, taskRepository, sessionService, config.GetEnvDuration("IMPERSONATION_TOKEN_TTL", 15*time.Minute))
+	consistencyService := services.NewConsistencyService(taskRepository)
+	adminHandler := handlers.NewAdminHandler(adminService, auditService, consistencyService, securityEventService)
+
+	oauthClientRepository := repository.NewOAuthClientRepository(db)
+	oauthCodeRepository := repository.NewOAuthCodeRepository(db)
+	oauthClientService := services.NewOAuthClientService(oauthClientRepository, sessionService)
+	oauthService := services.NewOAuthService(oauthClientService, oauthCodeRepository, userRepository, sessionService, tokenService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, oauthClientService)
+	oauthClientHandler := handlers.NewOAuthClientHandler(oauthClientService)
+
+	dataExportRepository := repository.NewDataExportRepository(db)
+	dataExportService := services.NewDataExportService(
+		dataExportRepository,
+		userRepository,
+		taskRepository,
+		securityEventRepository,
+		sessionService,
+		tokenService,
+		os.Getenv("APP_BASE_URL"),
+		services.DataExportConfig{
+			Retention: config.GetEnvDuration("EXPORT_RETENTION", 24*time.Hour),
+			LinkTTL:   config.GetEnvDuration("EXPORT_LINK_TTL", 15*time.Minute),
+		},
+	)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService)
+
+	accountPurgeService := services.NewAccountPurgeService(
+		repository.NewTransactor(db),
+		userRepository,
+		taskRepository,
+		sessionService,
+		personalAccessTokenRepository,
+		actionTokenRepository,
+		lockoutEventRepository,
+		securityEventRepository,
+		userIdentityRepository,
+		oauthClientService,
+		dataExportRepository,
+	)
+
+	ensureIndexes(
+		userRepository,
+		revokedTokenRepository,
+		sessionRepository,
+		actionTokenRepository,
+		loginAttemptRepository,
+		lockoutEventRepository,
+		personalAccessTokenRepository,
+		auditLogRepository,
+		oauthClientRepository,
+		oauthCodeRepository,
+		userIdentityRepository,
+		oidcRequestRepository,
+		dataExportRepository,
+		securityEventRepository,
+	)
 
-	routers.UserRouter(app, userHandler)
-	routers.TaskRouter(app, taskHandler)
+	bootstrapAdmins(userRepository, os.Getenv("ADMIN_EMAILS"))
+
+	go accountPurgeService.Run(context.Background(), config.GetEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour))
+	go dataExportService.Run(context.Background(), config.GetEnvDuration("DATA_EXPORT_INTERVAL", time.Minute))
+	go consistencyService.Run(context.Background(), config.GetEnvDuration("CONSISTENCY_CHECK_INTERVAL", 24*time.Hour))
+
+	auth := middleware.Auth(tokenService, sessionService, auditService)
+	tokenAuth := middleware.TokenAuth(tokenService, sessionService, auditService, personalAccessTokenService)
+	verified := middleware.RequireVerifiedEmail(os.Getenv("UNVERIFIED_ACCOUNT_MODE"))
+
+	wellKnownHandler := handlers.NewWellKnownHandler()
+
+	routers.WellKnownRouter(app, wellKnownHandler)
+	routers.UserRouter(app, userHandler, auth, tokenAuth)
+	routers.SessionRouter(app, sessionHandler, auth)
+	routers.SecurityEventRouter(app, securityEventHandler, auth)
+	routers.PasswordRouter(app, passwordHandler, auth)
+	routers.EmailRouter(app, emailHandler, auth)
+	routers.TwoFactorRouter(app, twoFactorHandler, auth)
+	routers.IdentityRouter(app, identityHandler, auth)
+	routers.DataExportRouter(app, dataExportHandler, auth)
+	routers.PersonalAccessTokenRouter(app, personalAccessTokenHandler, auth)
+	routers.AdminRouter(app, adminHandler, auth)
+	routers.OAuthRouter(app, oauthHandler, oauthClientHandler, auth)
+	routers.TaskRouter(app, taskHandler, tokenAuth, verified)
 
 	app.Listen(":8080")
-}
\ No newline at end of file
+}
+
+type indexer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+func ensureIndexes(repositories ...indexer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, repo := range repositories {
+		if err := repo.EnsureIndexes(ctx); err != nil {
+			log.Fatalf("Failed to create indexes: %v", err)
+		}
+	}
+}
+
+// bootstrapAdmins grants the admin role to the accounts listed in ADMIN_EMAILS, so the
+// first admin does not have to be created by hand in Mongo.
+func bootstrapAdmins(userRepository repository.UserRepository, emails string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, email := range strings.Split(emails, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		if err := userRepository.SetRoleByEmail(ctx, email, models.RoleAdmin); err != nil {
+			if apperr.Is(err, apperr.KindNotFound) {
+				log.Printf("ADMIN_EMAILS: no account with email %s yet", email)
+				continue
+			}
+			log.Fatalf("Failed to grant admin role: %v", err)
+		}
+	}
+}
+
+// oidcProviders reads the providers listed in OIDC_PROVIDERS. Each name needs
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and optionally OIDC_<NAME>_CLIENT_SECRET.
+func oidcProviders() []*oidc.Provider {
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if redirectURL == "" {
+		redirectURL = frontendURL() + "/oidc/callback"
+	}
+
+	providers := []*oidc.Provider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		issuer := os.Getenv(prefix + "ISSUER")
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		if issuer == "" || clientID == "" {
+			log.Fatalf("OIDC provider %s needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         name,
+			Issuer:       issuer,
+			ClientID:     clientID,
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  redirectURL,
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}))
+	}
+
+	return providers
+}
+
+func frontendURL() string {
+	if url := os.Getenv("APP_FRONTEND_URL"); url != "" {
+		return url
+	}
+
+	return os.Getenv("APP_BASE_URL")
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	UserIDKey              = "user_id"
+	ClaimsKey              = "claims"
+	UserKey                = "user"
+	PersonalAccessTokenKey = "personal_access_token"
+)
+
+const authRealm = "todolist-auth-fiber"
+
+// Auth only accepts access tokens issued at login or to an impersonating admin.
+func Auth(tokenService services.TokenService, sessionService services.SessionService, auditService services.AuditService) fiber.Handler {
+	return authenticate(tokenService, sessionService, auditService, nil)
+}
+
+// TokenAuth also accepts personal access tokens and tokens issued to OAuth clients.
+// Routes behind it must say which scope they need with RequireScope.
+func TokenAuth(tokenService services.TokenService, sessionService services.SessionService, auditService services.AuditService, pats services.PersonalAccessTokenService) fiber.Handler {
+	return authenticate(tokenService, sessionService, auditService, pats)
+}
+
+func authenticate(tokenService services.TokenService, sessionService services.SessionService, auditService services.AuditService, pats services.PersonalAccessTokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get(fiber.HeaderAuthorization)
+		if authHeader == "" {
+			return unauthorized("", "")
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader || tokenString == "" {
+			return unauthorized("invalid_request", "Invalid Authorization header format")
+		}
+
+		if services.IsPersonalAccessToken(tokenString) {
+			if pats == nil {
+				return unauthorized("invalid_token", "Personal access tokens are not accepted here")
+			}
+
+			pat, user, err := pats.Authenticate(c.Context(), tokenString)
+			if err != nil {
+				if apperr.IsInternal(err) {
+					return err
+				}
+
+				return unauthorized("invalid_token", "The personal access token is invalid or expired")
+			}
+
+			c.Locals(UserIDKey, user.ID)
+			c.Locals(UserKey, user)
+			c.Locals(PersonalAccessTokenKey, pat)
+
+			return c.Next()
+		}
+
+		claims, user, err := tokenService.Validate(c.Context(), tokenString, utils.AccessTokenType)
+		if err != nil {
+			if apperr.IsInternal(err) {
+				return err
+			}
+
+			return unauthorized("invalid_token", "The access token is invalid, expired or revoked")
+		}
+
+		if claims.ClientID != "" && pats == nil {
+			return unauthorized("invalid_token", "Tokens issued to OAuth clients are not accepted here")
+		}
+
+		if claims.IsImpersonation() {
+			if err := auditImpersonatedRequest(c, auditService, claims, user); err != nil {
+				return err
+			}
+		} else if sessionID, err := claims.SessionObjectID(); err == nil {
+			if err := sessionService.Touch(c.Context(), sessionID); err != nil {
+				log.Printf("failed to record usage of session %s: %v", sessionID.Hex(), err)
+			}
+		}
+
+		c.Locals(UserIDKey, user.ID)
+		c.Locals(ClaimsKey, claims)
+		c.Locals(UserKey, user)
+
+		return c.Next()
+	}
+}
+
+// RequireScope checks the scopes of personal access tokens and OAuth client tokens.
+// Login tokens carry every scope.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		granted := true
+		if pat := PersonalAccessToken(c); pat != nil {
+			granted = pat.HasScope(scope)
+		} else if claims := Claims(c); claims != nil && claims.ClientID != "" {
+			granted = claims.HasScope(scope)
+		}
+
+		if granted {
+			return c.Next()
+		}
+
+		return apperr.Forbidden("The token does not have the required scope").
+			WithDetails(scope).
+			WithChallenge(`Bearer realm="` + authRealm + `", error="insufficient_scope", scope="` + scope + `"`)
+	}
+}
+
+func UserID(c *fiber.Ctx) primitive.ObjectID {
+	userID, ok := c.Locals(UserIDKey).(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID
+	}
+
+	return userID
+}
+
+func Claims(c *fiber.Ctx) *utils.Claims {
+	claims, ok := c.Locals(ClaimsKey).(*utils.Claims)
+	if !ok {
+		return nil
+	}
+
+	return claims
+}
+
+func User(c *fiber.Ctx) *models.User {
+	user, ok := c.Locals(UserKey).(*models.User)
+	if !ok {
+		return nil
+	}
+
+	return user
+}
+
+func PersonalAccessToken(c *fiber.Ctx) *models.PersonalAccessToken {
+	pat, ok := c.Locals(PersonalAccessTokenKey).(*models.PersonalAccessToken)
+	if !ok {
+		return nil
+	}
+
+	return pat
+}
+
+func unauthorized(errorCode string, description string) error {
+	challenge := `Bearer realm="` + authRealm + `"`
+	if errorCode != "" {
+		challenge += `, error="` + errorCode + `", error_description="` + description + `"`
+	}
+
+	return apperr.Unauthorized("You are not authorized").WithChallenge(challenge)
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakePersonalAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	tokens map[string]*models.PersonalAccessToken
+}
+
+func (r *fakePersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error) {
+	token.ID = primitive.NewObjectID()
+	r.tokens[token.TokenHash] = token
+	return token, nil
+}
+
+func (r *fakePersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakePersonalAccessTokenRepository) Touch(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[primitive.ObjectID]*models.User
+}
+
+func (r *fakeUserRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func TestPersonalAccessTokenScopes(t *testing.T) {
+	active := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com"}
+	disabled := &models.User{ID: primitive.NewObjectID(), Email: "grace@example.com", Disabled: true}
+
+	tokenRepo := &fakePersonalAccessTokenRepository{tokens: map[string]*models.PersonalAccessToken{}}
+	pats := services.NewPersonalAccessTokenService(tokenRepo, &fakeUserRepository{users: map[primitive.ObjectID]*models.User{
+		active.ID:   active,
+		disabled.ID: disabled,
+	}})
+
+	create := func(user *models.User, scopes ...string) (string, *models.PersonalAccessToken) {
+		token, record, err := pats.Create(context.Background(), user, "ci", scopes, 0)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		return token, record
+	}
+
+	readOnly, _ := create(active, models.ScopeTasksRead)
+	readWrite, _ := create(active, models.ScopeTasksRead, models.ScopeTasksWrite)
+	ofDisabled, _ := create(disabled, models.ScopeTasksRead)
+	expired, expiredRecord := create(active, models.ScopeTasksRead)
+	past := time.Now().Add(-time.Minute)
+	expiredRecord.ExpiresAt = &past
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/tasks", TokenAuth(nil, nil, nil, pats), RequireScope(models.ScopeTasksRead), ok)
+	app.Post("/tasks", TokenAuth(nil, nil, nil, pats), RequireScope(models.ScopeTasksWrite), ok)
+	app.Get("/account", TokenAuth(nil, nil, nil, pats), RequireScope(models.ScopeAccountRead), ok)
+	app.Put("/password", Auth(nil, nil, nil), ok)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		token         string
+		wantCode      int
+		wantChallenge string
+	}{
+		{name: "granted read", method: fiber.MethodGet, path: "/tasks", token: readOnly, wantCode: fiber.StatusOK},
+		{name: "granted write", method: fiber.MethodPost, path: "/tasks", token: readWrite, wantCode: fiber.StatusOK},
+		{
+			name:          "write without tasks:write",
+			method:        fiber.MethodPost,
+			path:          "/tasks",
+			token:         readOnly,
+			wantCode:      fiber.StatusForbidden,
+			wantChallenge: `error="insufficient_scope", scope="tasks:write"`,
+		},
+		{
+			name:          "account without account:read",
+			method:        fiber.MethodGet,
+			path:          "/account",
+			token:         readWrite,
+			wantCode:      fiber.StatusForbidden,
+			wantChallenge: `error="insufficient_scope", scope="account:read"`,
+		},
+		{
+			name:          "login-only route",
+			method:        fiber.MethodPut,
+			path:          "/password",
+			token:         readWrite,
+			wantCode:      fiber.StatusUnauthorized,
+			wantChallenge: `error="invalid_token"`,
+		},
+		{
+			name:          "expired token",
+			method:        fiber.MethodGet,
+			path:          "/tasks",
+			token:         expired,
+			wantCode:      fiber.StatusUnauthorized,
+			wantChallenge: `error="invalid_token"`,
+		},
+		{
+			name:          "disabled account",
+			method:        fiber.MethodGet,
+			path:          "/tasks",
+			token:         ofDisabled,
+			wantCode:      fiber.StatusUnauthorized,
+			wantChallenge: `error="invalid_token"`,
+		},
+		{
+			name:          "unknown token",
+			method:        fiber.MethodGet,
+			path:          "/tasks",
+			token:         services.PersonalAccessTokenPrefix + "unknown",
+			wantCode:      fiber.StatusUnauthorized,
+			wantChallenge: `error="invalid_token"`,
+		},
+		{
+			name:          "no token",
+			method:        fiber.MethodGet,
+			path:          "/tasks",
+			wantCode:      fiber.StatusUnauthorized,
+			wantChallenge: `Bearer realm="` + authRealm + `"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			challenge := resp.Header.Get(fiber.HeaderWWWAuthenticate)
+			if !strings.Contains(challenge, tt.wantChallenge) || (tt.wantChallenge == "") != (challenge == "") {
+				t.Fatalf("WWW-Authenticate = %q, want it to contain %q", challenge, tt.wantChallenge)
+			}
+		})
+	}
+}
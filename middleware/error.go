@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"time"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/res"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler answers the errors returned by handlers. Typed errors get their status and
+// message; anything else is logged and answered with a generic 500, so database and
+// driver messages never reach the client.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(
+			res.ResponseHttp[string]{
+				Timestamp: time.Now(),
+				Body:      "",
+				Code:      fiberErr.Code,
+				Status:    false,
+				Message:   fiberErr.Message,
+			},
+		)
+	}
+
+	appErr := apperr.As(err)
+	status := appErr.Kind.Status()
+
+	message := appErr.Message
+	if status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+
+		if appErr.Kind == apperr.KindInternal {
+			message = apperr.InternalMessage
+		}
+	}
+
+	if appErr.Challenge != "" {
+		c.Set(fiber.HeaderWWWAuthenticate, appErr.Challenge)
+	}
+
+	var body interface{} = ""
+	if appErr.Details != nil {
+		body = appErr.Details
+	}
+
+	return c.Status(status).JSON(
+		res.ResponseHttp[interface{}]{
+			Timestamp: time.Now(),
+			Body:      body,
+			Code:      status,
+			Status:    false,
+			Message:   message,
+		},
+	)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"todolist-auth-fiber/utils/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type errorResponse struct {
+	Body    interface{} `json:"body"`
+	Code    int         `json:"code"`
+	Status  bool        `json:"status"`
+	Message string      `json:"message"`
+}
+
+func TestErrorHandler(t *testing.T) {
+	secret := errors.New("mongo: connection to 10.0.0.7:27017 refused")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      int
+		wantMessage   string
+		wantBody      interface{}
+		wantChallenge string
+		wantLogged    bool
+	}{
+		{
+			name:        "not found",
+			err:         apperr.NotFound("Task not found"),
+			wantCode:    fiber.StatusNotFound,
+			wantMessage: "Task not found",
+			wantBody:    "",
+		},
+		{
+			name:        "conflict wrapping a driver error",
+			err:         apperr.Wrap(apperr.KindConflict, "Email already in use", secret),
+			wantCode:    fiber.StatusConflict,
+			wantMessage: "Email already in use",
+			wantBody:    "",
+		},
+		{
+			name:        "details become the body",
+			err:         apperr.Forbidden("You do not have permission to do this").WithDetails("tasks:read"),
+			wantCode:    fiber.StatusForbidden,
+			wantMessage: "You do not have permission to do this",
+			wantBody:    "tasks:read",
+		},
+		{
+			name:          "challenge becomes the WWW-Authenticate header",
+			err:           apperr.Unauthorized("You are not authorized").WithChallenge(`Bearer realm="test", error="invalid_token"`),
+			wantCode:      fiber.StatusUnauthorized,
+			wantMessage:   "You are not authorized",
+			wantBody:      "",
+			wantChallenge: `Bearer realm="test", error="invalid_token"`,
+		},
+		{
+			name:        "untyped error is hidden",
+			err:         fmt.Errorf("fail to search user: %w", secret),
+			wantCode:    fiber.StatusInternalServerError,
+			wantMessage: apperr.InternalMessage,
+			wantBody:    "",
+			wantLogged:  true,
+		},
+		{
+			name:        "upstream keeps its message",
+			err:         apperr.Upstream("The corp provider is unavailable, please try again later", secret),
+			wantCode:    fiber.StatusBadGateway,
+			wantMessage: "The corp provider is unavailable, please try again later",
+			wantBody:    "",
+			wantLogged:  true,
+		},
+		{
+			name:        "fiber error",
+			err:         fiber.ErrMethodNotAllowed,
+			wantCode:    fiber.StatusMethodNotAllowed,
+			wantMessage: "Method Not Allowed",
+			wantBody:    "",
+		},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/", func(c *fiber.Ctx) error { return tt.err })
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			raw, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			if challenge := resp.Header.Get(fiber.HeaderWWWAuthenticate); challenge != tt.wantChallenge {
+				t.Fatalf("WWW-Authenticate = %q, want %q", challenge, tt.wantChallenge)
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("response is not JSON: %s", raw)
+			}
+
+			if body.Code != tt.wantCode || body.Status || body.Message != tt.wantMessage || body.Body != tt.wantBody {
+				t.Fatalf("response = %+v, want code %d message %q body %v", body, tt.wantCode, tt.wantMessage, tt.wantBody)
+			}
+
+			if strings.Contains(string(raw), "10.0.0.7") {
+				t.Fatalf("response leaks the cause: %s", raw)
+			}
+
+			logged := strings.Contains(logs.String(), "10.0.0.7")
+			if logged != tt.wantLogged {
+				t.Fatalf("cause logged = %v, want %v: %q", logged, tt.wantLogged, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RejectImpersonation guards the routes an impersonating admin must not reach: deleting
+// the account, changing credentials or sessions, and anything that hands out access.
+func RejectImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := Claims(c)
+		if claims == nil || !claims.IsImpersonation() {
+			return c.Next()
+		}
+
+		return apperr.Forbidden("This action is not allowed while impersonating a user").WithDetails("impersonation_forbidden")
+	}
+}
+
+// auditImpersonatedRequest records the request before it runs and fails closed, like the
+// admin API: nothing is done on behalf of a user without a trace.
+func auditImpersonatedRequest(c *fiber.Ctx, auditService services.AuditService, claims *utils.Claims, user *models.User) error {
+	actorID, err := claims.ActorObjectID()
+	if err != nil {
+		return err
+	}
+
+	return auditService.Record(c.Context(), &models.AuditLog{
+		ActorID:  actorID,
+		Action:   models.AuditImpersonatedRequest,
+		TargetID: &user.ID,
+		Details: map[string]string{
+			"method": c.Method(),
+			"path":   c.Path(),
+			"jti":    claims.ID,
+		},
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+}
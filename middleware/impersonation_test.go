@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/services"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/keys"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	utils.ConfigureKeys(keys.NewHMAC("test", []byte("middleware-test-secret")))
+	os.Exit(m.Run())
+}
+
+type fakeRevokedTokenRepository struct{}
+
+func (r *fakeRevokedTokenRepository) EnsureIndexes(ctx context.Context) error { return nil }
+
+func (r *fakeRevokedTokenRepository) Add(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time) error {
+	return nil
+}
+
+func (r *fakeRevokedTokenRepository) Exists(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+type fakeAuditService struct {
+	services.AuditService
+	recorded []models.AuditLog
+	fail     bool
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, entry *models.AuditLog) error {
+	if s.fail {
+		return errors.New("audit_logs unavailable")
+	}
+	s.recorded = append(s.recorded, *entry)
+	return nil
+}
+
+func TestImpersonation(t *testing.T) {
+	admin := &models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com"}
+
+	users := &fakeUserRepository{users: map[primitive.ObjectID]*models.User{admin.ID: admin, user.ID: user}}
+	tokenService := services.NewTokenService(users, &fakeRevokedTokenRepository{})
+
+	impersonation, _, err := utils.GenerateImpersonationToken(user, admin, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := utils.GenerateToken(user, primitive.NilObjectID, utils.AccessTokenType, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		path        string
+		auditFails  bool
+		wantCode    int
+		wantBody    string
+		wantAudited bool
+	}{
+		{name: "allowed route", token: impersonation, path: "/tasks", wantCode: fiber.StatusOK, wantAudited: true},
+		{name: "refused route", token: impersonation, path: "/password", wantCode: fiber.StatusForbidden, wantBody: "impersonation_forbidden", wantAudited: true},
+		{name: "audit log unavailable", token: impersonation, path: "/tasks", auditFails: true, wantCode: fiber.StatusInternalServerError},
+		{name: "login token on refused route", token: login, path: "/password", wantCode: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &fakeAuditService{fail: tt.auditFails}
+			reached := false
+			ok := func(c *fiber.Ctx) error {
+				reached = true
+				return c.SendStatus(fiber.StatusOK)
+			}
+
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			auth := Auth(tokenService, nil, audit)
+			app.Get("/tasks", auth, ok)
+			app.Get("/password", auth, RejectImpersonation(), ok)
+
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if reached != (tt.wantCode == fiber.StatusOK) {
+				t.Fatalf("handler reached = %v with status %d", reached, resp.StatusCode)
+			}
+
+			if tt.wantBody != "" {
+				raw, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var body errorResponse
+				if err := json.Unmarshal(raw, &body); err != nil || body.Body != tt.wantBody {
+					t.Fatalf("response = %s, want body %q", raw, tt.wantBody)
+				}
+			}
+
+			if audited := len(audit.recorded) == 1; audited != tt.wantAudited {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantAudited)
+			}
+			if tt.wantAudited {
+				entry := audit.recorded[0]
+				if entry.Action != models.AuditImpersonatedRequest || entry.ActorID != admin.ID || entry.TargetID == nil || *entry.TargetID != user.ID {
+					t.Fatalf("audit entry = %+v, want the admin acting on the user", entry)
+				}
+				if entry.Details["path"] != tt.path {
+					t.Fatalf("audit entry path = %q, want %q", entry.Details["path"], tt.path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"todolist-auth-fiber/utils/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission checks the role of the user loaded by Auth, not the token claims,
+// so a role change applies immediately.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := User(c)
+		if user != nil && user.Can(permission) {
+			return c.Next()
+		}
+
+		return apperr.Forbidden("You do not have permission to do this").WithDetails(permission)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"todolist-auth-fiber/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *models.User
+		wantCode int
+	}{
+		{name: "admin", user: &models.User{Role: models.RoleAdmin}, wantCode: fiber.StatusOK},
+		{name: "user", user: &models.User{Role: models.RoleUser}, wantCode: fiber.StatusForbidden},
+		{name: "account created before roles", user: &models.User{}, wantCode: fiber.StatusForbidden},
+		{name: "unknown role", user: &models.User{Role: "owner"}, wantCode: fiber.StatusForbidden},
+		{name: "no user", wantCode: fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/",
+				func(c *fiber.Ctx) error {
+					if tt.user != nil {
+						c.Locals(UserKey, tt.user)
+					}
+					return c.Next()
+				},
+				RequirePermission(models.PermissionUsersManage),
+				func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) },
+			)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"todolist-auth-fiber/utils/apperr"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	UnverifiedFull     = "full"
+	UnverifiedReadOnly = "read_only"
+	UnverifiedBlocked  = "blocked"
+)
+
+// RequireVerifiedEmail limits accounts that did not confirm their email according to mode:
+// "full" lets them through, "read_only" only allows safe methods and "blocked" denies everything.
+func RequireVerifiedEmail(mode string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := User(c)
+		if user == nil || user.EmailVerified || mode == "" || mode == UnverifiedFull {
+			return c.Next()
+		}
+
+		if mode == UnverifiedReadOnly && (c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead) {
+			return c.Next()
+		}
+
+		return apperr.Forbidden("Please verify your email to continue").WithDetails("email_not_verified")
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ActionPasswordReset     = "password_reset"
+	ActionEmailChange       = "email_change"
+	ActionEmailChangeCancel = "email_change_cancel"
+	ActionMagicLink         = "magic_link"
+	ActionAccountRecovery   = "account_recovery"
+)
+
+type ActionToken struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Purpose   string             `json:"purpose" bson:"purpose"`
+	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+	CreatedAt *time.Time         `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditAdminUsersList       = "admin.users.list"
+	AuditAdminUsersRead       = "admin.users.read"
+	AuditAdminUsersDisable    = "admin.users.disable"
+	AuditAdminUsersEnable     = "admin.users.enable"
+	AuditAdminUsersRole       = "admin.users.role"
+	AuditAdminSessionsRevoke  = "admin.sessions.revoke"
+	AuditAdminTasksCount      = "admin.tasks.count"
+	AuditAdminAuditRead       = "admin.audit.read"
+	AuditAdminConsistencyRead = "admin.consistency.read"
+	AuditAdminSecurityRead    = "admin.security_events.read"
+	AuditAdminImpersonate     = "admin.users.impersonate"
+	// AuditImpersonatedRequest is written for every request made with an impersonation token.
+	AuditImpersonatedRequest = "impersonation.request"
+)
+
+type AuditLog struct {
+	ID        primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	ActorID   primitive.ObjectID  `json:"actor_id" bson:"actor_id"`
+	Action    string              `json:"action" bson:"action"`
+	TargetID  *primitive.ObjectID `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	Details   map[string]string   `json:"details,omitempty" bson:"details,omitempty"`
+	IP        string              `json:"ip" bson:"ip"`
+	UserAgent string              `json:"user_agent" bson:"user_agent"`
+	CreatedAt *time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrphanedTasks groups the tasks that point to a user_id with no matching user.
+type OrphanedTasks struct {
+	UserID          primitive.ObjectID `json:"user_id" bson:"_id"`
+	Count           int64              `json:"count" bson:"count"`
+	OldestCreatedAt *time.Time         `json:"oldest_created_at" bson:"oldest_created_at"`
+	NewestCreatedAt *time.Time         `json:"newest_created_at" bson:"newest_created_at"`
+}
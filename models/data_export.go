@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a job that packages everything an account owns into a zip archive. The
+// archive itself is kept in GridFS under FileID once the job is ready.
+type DataExport struct {
+	ID          primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Status      string              `json:"status" bson:"status"`
+	Error       string              `json:"error,omitempty" bson:"error,omitempty"`
+	FileID      *primitive.ObjectID `json:"-" bson:"file_id,omitempty"`
+	Size        int64               `json:"size" bson:"size"`
+	CreatedAt   *time.Time          `json:"created_at" bson:"created_at"`
+	StartedAt   *time.Time          `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	ExpiresAt   time.Time           `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OIDCPurposeLogin = "login"
+	OIDCPurposeLink  = "link"
+)
+
+// UserIdentity links an account to a subject at an external OpenID Connect provider.
+type UserIdentity struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Provider    string             `json:"provider" bson:"provider"`
+	Subject     string             `json:"subject" bson:"subject"`
+	Email       string             `json:"email" bson:"email"`
+	CreatedAt   *time.Time         `json:"created_at" bson:"created_at"`
+	LastLoginAt *time.Time         `json:"last_login_at" bson:"last_login_at,omitempty"`
+}
+
+// OIDCAuthRequest keeps what a login or link flow needs between the redirect to the
+// provider and its callback. Only the hash of the state is stored.
+type OIDCAuthRequest struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	StateHash    string             `json:"-" bson:"state_hash"`
+	Provider     string             `json:"provider" bson:"provider"`
+	Purpose      string             `json:"purpose" bson:"purpose"`
+	UserID       primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Nonce        string             `json:"-" bson:"nonce"`
+	CodeVerifier string             `json:"-" bson:"code_verifier"`
+	ExpiresAt    time.Time          `json:"expires_at" bson:"expires_at"`
+	CreatedAt    *time.Time         `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+)
+
+type LoginAttempt struct {
+	ID            string     `json:"key" bson:"_id"`
+	Failures      int        `json:"failures" bson:"failures"`
+	LockedUntil   *time.Time `json:"locked_until" bson:"locked_until"`
+	LastFailureAt *time.Time `json:"last_failure_at" bson:"last_failure_at"`
+	ExpiresAt     time.Time  `json:"expires_at" bson:"expires_at"`
+}
+
+type LockoutEvent struct {
+	ID          primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      *primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Scope       string              `json:"scope" bson:"scope"`
+	Email       string              `json:"email" bson:"email"`
+	IP          string              `json:"ip" bson:"ip"`
+	Failures    int                 `json:"failures" bson:"failures"`
+	LockedUntil time.Time           `json:"locked_until" bson:"locked_until"`
+	Seen        bool                `json:"seen" bson:"seen"`
+	CreatedAt   *time.Time          `json:"created_at" bson:"created_at"`
+}
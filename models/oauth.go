@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OAuthClient struct {
+	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ClientID     string             `json:"client_id" bson:"client_id"`
+	SecretHash   string             `json:"-" bson:"secret_hash,omitempty"`
+	Name         string             `json:"name" bson:"name"`
+	RedirectURIs []string           `json:"redirect_uris" bson:"redirect_uris"`
+	Scopes       []string           `json:"scopes" bson:"scopes"`
+	OwnerID      primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	CreatedAt    *time.Time         `json:"created_at" bson:"created_at"`
+}
+
+// Confidential clients authenticate with a secret; public clients (SPAs, mobile apps) only have PKCE.
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	for _, allowed := range c.Scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OAuthAuthorizationCode keeps RedirectURI exactly as the authorization request sent it,
+// empty when it was omitted, so the token request can be checked against it.
+type OAuthAuthorizationCode struct {
+	ID                  primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CodeHash            string             `json:"-" bson:"code_hash"`
+	ClientID            string             `json:"client_id" bson:"client_id"`
+	UserID              primitive.ObjectID `json:"user_id" bson:"user_id"`
+	RedirectURI         string             `json:"redirect_uri" bson:"redirect_uri"`
+	Scopes              []string           `json:"scopes" bson:"scopes"`
+	CodeChallenge       string             `json:"-" bson:"code_challenge"`
+	CodeChallengeMethod string             `json:"-" bson:"code_challenge_method"`
+	ExpiresAt           time.Time          `json:"expires_at" bson:"expires_at"`
+	CreatedAt           *time.Time         `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	PermissionUsersRead      = "users:read"
+	PermissionUsersManage    = "users:manage"
+	PermissionSessionsManage = "sessions:manage"
+	PermissionTasksStats     = "tasks:stats"
+	PermissionAuditRead      = "audit:read"
+	PermissionImpersonate    = "users:impersonate"
+)
+
+var RolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersManage,
+		PermissionSessionsManage,
+		PermissionTasksStats,
+		PermissionAuditRead,
+		PermissionImpersonate,
+	},
+}
+
+func IsRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RoleName treats accounts created before roles existed as plain users.
+func (u *User) RoleName() string {
+	if u.Role == "" {
+		return RoleUser
+	}
+
+	return u.Role
+}
+
+func (u *User) Permissions() []string {
+	return RolePermissions[u.RoleName()]
+}
+
+func (u *User) Can(permission string) bool {
+	for _, granted := range u.Permissions() {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
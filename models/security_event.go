@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SecurityLoginSucceeded           = "login.succeeded"
+	SecurityLoginFailed              = "login.failed"
+	SecurityAccountCreated           = "account.created"
+	SecurityAccountUpdated           = "account.updated"
+	SecurityAccountDeletionRequested = "account.deletion_requested"
+	SecurityAccountRecovered         = "account.recovered"
+	SecurityPasswordChanged          = "password.changed"
+	SecurityPasswordReset            = "password.reset"
+	SecurityEmailChanged             = "email.changed"
+	SecurityTokensRevoked            = "tokens.revoked"
+	SecuritySessionRevoked           = "session.revoked"
+	SecurityTwoFactorEnabled         = "2fa.enabled"
+	SecurityTwoFactorDisabled        = "2fa.disabled"
+	SecurityImpersonationStarted     = "impersonation.started"
+)
+
+// SecurityEvent records something that happened to an account's credentials or sessions.
+// UserID is empty for failed logins with an unknown email; Email keeps what was typed.
+type SecurityEvent struct {
+	ID        primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Type      string              `json:"type" bson:"type"`
+	Email     string              `json:"email,omitempty" bson:"email,omitempty"`
+	IP        string              `json:"ip" bson:"ip"`
+	UserAgent string              `json:"user_agent" bson:"user_agent"`
+	Details   map[string]string   `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt *time.Time          `json:"created_at" bson:"created_at"`
+}
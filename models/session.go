@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Session struct {
+	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID           primitive.ObjectID `json:"user_id" bson:"user_id"`
+	UserAgent        string             `json:"user_agent" bson:"user_agent"`
+	IP               string             `json:"ip" bson:"ip"`
+	RefreshTokenHash string             `json:"-" bson:"refresh_token_hash"`
+	AccessTokenID    string             `json:"-" bson:"access_token_id"`
+	ClientID         string             `json:"client_id,omitempty" bson:"client_id,omitempty"`
+	Scopes           []string           `json:"scopes,omitempty" bson:"scopes,omitempty"`
+	CreatedAt        *time.Time         `json:"created_at" bson:"created_at"`
+	LastUsedAt       *time.Time         `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt        *time.Time         `json:"expires_at" bson:"expires_at"`
+}
@@ -7,11 +7,29 @@ import (
 )
 
 type User struct {
-	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Username     string             `json:"username" bson:"username"`
-	Email        string             `json:"email" bson:"email"`
-	Password     string             `json:"password" bson:"password"`
-	RefreshToken string             `json:"refresh_token" bson:"refresh_token"`
-	CreatedAt    *time.Time         `json:"created_at" bson:"created_at"`
-	UpdatedAt    *time.Time         `json:"updated_at" bson:"updated_at"`
-}
\ No newline at end of file
+	ID                 primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Username           string             `json:"username" bson:"username"`
+	Email              string             `json:"email" bson:"email"`
+	EmailVerified      bool               `json:"email_verified" bson:"email_verified"`
+	EmailVerifiedAt    *time.Time         `json:"email_verified_at" bson:"email_verified_at"`
+	Password           string             `json:"password" bson:"password"`
+	PasswordChangedAt  *time.Time         `json:"password_changed_at" bson:"password_changed_at"`
+	CredentialsVersion int                `json:"-" bson:"credentials_version"`
+	Role               string             `json:"role" bson:"role,omitempty"`
+	Disabled           bool               `json:"disabled" bson:"disabled"`
+	DisabledAt         *time.Time         `json:"disabled_at" bson:"disabled_at,omitempty"`
+	DeletedAt          *time.Time         `json:"deleted_at" bson:"deleted_at,omitempty"`
+	PurgeAt            *time.Time         `json:"purge_at" bson:"purge_at,omitempty"`
+	TOTPSecret         string             `json:"-" bson:"totp_secret,omitempty"`
+	TOTPEnabled        bool               `json:"totp_enabled" bson:"totp_enabled"`
+	TOTPLastCounter    int64              `json:"-" bson:"totp_last_counter,omitempty"`
+	RecoveryCodes      []string           `json:"-" bson:"recovery_codes,omitempty"`
+	CreatedAt          *time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt          *time.Time         `json:"updated_at" bson:"updated_at"`
+}
+
+// PendingDeletion reports whether the user asked to delete the account and it is waiting
+// for the purge at PurgeAt.
+func (u *User) PendingDeletion() bool {
+	return u.DeletedAt != nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailChangeIndex is the partial unique index that reserves the address of a pending
+// email change.
+const emailChangeIndex = "email_1"
+
+type ActionTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, token *models.ActionToken) (*models.ActionToken, error)
+	GetByHash(ctx context.Context, purpose string, tokenHash string) (*models.ActionToken, error)
+	Consume(ctx context.Context, purpose string, tokenHash string) (*models.ActionToken, error)
+	DeleteAllByUserIdAndPurpose(ctx context.Context, userID primitive.ObjectID, purpose string) (int64, error)
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	ExistsByEmail(ctx context.Context, purpose string, email string) (bool, error)
+}
+
+type actionTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewActionTokenRepository(db *mongo.Database) ActionTokenRepository {
+	return &actionTokenRepository{
+		collection: db.Collection("action_tokens"),
+	}
+}
+
+func (r *actionTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{
+			// A pending email change reserves its address, so two accounts cannot claim it at once.
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().
+				SetName(emailChangeIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"purpose": models.ActionEmailChange}),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create action_tokens indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *actionTokenRepository) Create(ctx context.Context, token *models.ActionToken) (*models.ActionToken, error) {
+	token.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	token.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, token); err != nil {
+		if isDuplicateKeyOn(err, emailChangeIndex) {
+			return nil, apperr.Conflict("Email already in use")
+		}
+		return nil, fmt.Errorf("Error the save token in database %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *actionTokenRepository) GetByHash(ctx context.Context, purpose string, tokenHash string) (*models.ActionToken, error) {
+	filter := bson.M{
+		"purpose":    purpose,
+		"token_hash": tokenHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var token models.ActionToken
+	err := r.collection.FindOne(ctx, filter).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to find token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Consume deletes the token while reading it, so each token can be redeemed only once.
+func (r *actionTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string) (*models.ActionToken, error) {
+	filter := bson.M{
+		"purpose":    purpose,
+		"token_hash": tokenHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var token models.ActionToken
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to consume token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *actionTokenRepository) DeleteAllByUserIdAndPurpose(ctx context.Context, userID primitive.ObjectID, purpose string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "purpose": purpose})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *actionTokenRepository) ExistsByEmail(ctx context.Context, purpose string, email string) (bool, error) {
+	filter := bson.M{
+		"purpose":    purpose,
+		"email":      email,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("fail to check email reservation: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *actionTokenRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
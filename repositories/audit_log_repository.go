@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogRepository is append-only on purpose: there is no way to edit or remove an entry.
+type AuditLogRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, entry *models.AuditLog) (*models.AuditLog, error)
+	GetAll(ctx context.Context, actorID *primitive.ObjectID, targetID *primitive.ObjectID, page, pageSize int) ([]models.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditLogRepository(db *mongo.Database) AuditLogRepository {
+	return &auditLogRepository{
+		collection: db.Collection("audit_logs"),
+	}
+}
+
+func (r *auditLogRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create audit_logs indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) (*models.AuditLog, error) {
+	entry.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	entry.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		return nil, fmt.Errorf("Error the save audit log in database %w", err)
+	}
+
+	return entry, nil
+}
+
+func (r *auditLogRepository) GetAll(ctx context.Context, actorID *primitive.ObjectID, targetID *primitive.ObjectID, page, pageSize int) ([]models.AuditLog, int64, error) {
+	filter := bson.M{}
+	if actorID != nil {
+		filter["actor_id"] = *actorID
+	}
+	if targetID != nil {
+		filter["target_id"] = *targetID
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditLog{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DataExportRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, export *models.DataExport) (*models.DataExport, error)
+	GetById(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*models.DataExport, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error)
+	ClaimNext(ctx context.Context) (*models.DataExport, error)
+	Upload(ctx context.Context, filename string, write func(w io.Writer) error) (primitive.ObjectID, int64, error)
+	MarkReady(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID, size int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason string, expiresAt time.Time) error
+	FailStale(ctx context.Context, startedBefore time.Time, expiresAt time.Time) (int64, error)
+	OpenFile(ctx context.Context, fileID primitive.ObjectID) (io.ReadCloser, int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type dataExportRepository struct {
+	collection *mongo.Collection
+	files      *gridfs.Bucket
+}
+
+func NewDataExportRepository(db *mongo.Database) DataExportRepository {
+	files, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("data_export_files"))
+	if err != nil {
+		panic(fmt.Errorf("fail to open data export bucket: %w", err))
+	}
+
+	return &dataExportRepository{
+		collection: db.Collection("data_exports"),
+		files:      files,
+	}
+}
+
+func (r *dataExportRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create data_exports indexes: %w", err)
+	}
+
+	return nil
+}
+
+// Create refuses a new export while another one of the same user is pending or running.
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) (*models.DataExport, error) {
+	active, err := r.collection.CountDocuments(ctx, bson.M{
+		"user_id": export.UserID,
+		"status":  bson.M{"$in": []string{models.DataExportPending, models.DataExportRunning}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to search data exports: %w", err)
+	}
+
+	if active > 0 {
+		return nil, apperr.Conflict("An export is already in progress")
+	}
+
+	export.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	export.Status = models.DataExportPending
+	export.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, export); err != nil {
+		return nil, fmt.Errorf("Error the save data export in database %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *dataExportRepository) GetById(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*models.DataExport, error) {
+	var export models.DataExport
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&export)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to search data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("fail to list data exports: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	exports := []models.DataExport{}
+	if err := cursor.All(ctx, &exports); err != nil {
+		return nil, fmt.Errorf("fail to list data exports: %w", err)
+	}
+
+	return exports, nil
+}
+
+// ClaimNext moves the oldest pending export to running in one update, so two workers
+// never build the same export.
+func (r *dataExportRepository) ClaimNext(ctx context.Context) (*models.DataExport, error) {
+	update := bson.M{"$set": bson.M{
+		"status":     models.DataExportRunning,
+		"started_at": time.Now(),
+	}}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var export models.DataExport
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"status": models.DataExportPending}, update, opts).Decode(&export)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to claim data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+// Upload streams what write produces into a new GridFS file. The partial file is removed
+// when write fails.
+func (r *dataExportRepository) Upload(ctx context.Context, filename string, write func(w io.Writer) error) (primitive.ObjectID, int64, error) {
+	stream, err := r.files.OpenUploadStream(filename)
+	if err != nil {
+		return primitive.NilObjectID, 0, fmt.Errorf("fail to open upload stream: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetWriteDeadline(deadline)
+	}
+
+	counter := &countingWriter{w: stream}
+	if err := write(counter); err != nil {
+		stream.Abort()
+		return primitive.NilObjectID, 0, err
+	}
+
+	if err := stream.Close(); err != nil {
+		return primitive.NilObjectID, 0, fmt.Errorf("fail to upload data export: %w", err)
+	}
+
+	fileID, ok := stream.FileID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, 0, fmt.Errorf("unexpected GridFS file id %v", stream.FileID)
+	}
+
+	return fileID, counter.n, nil
+}
+
+func (r *dataExportRepository) MarkReady(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID, size int64, expiresAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":       models.DataExportReady,
+		"file_id":      fileID,
+		"size":         size,
+		"completed_at": time.Now(),
+		"expires_at":   expiresAt,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.DataExportRunning}, update)
+	if err != nil {
+		return fmt.Errorf("fail to update data export: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("Data export not found")
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string, expiresAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":       models.DataExportFailed,
+		"error":        reason,
+		"completed_at": time.Now(),
+		"expires_at":   expiresAt,
+	}}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("fail to update data export: %w", err)
+	}
+
+	return nil
+}
+
+// FailStale gives up on exports whose worker died mid-build, so their users can ask again.
+func (r *dataExportRepository) FailStale(ctx context.Context, startedBefore time.Time, expiresAt time.Time) (int64, error) {
+	filter := bson.M{
+		"status":     models.DataExportRunning,
+		"started_at": bson.M{"$lt": startedBefore},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       models.DataExportFailed,
+		"error":        "The export was interrupted, please request a new one",
+		"completed_at": time.Now(),
+		"expires_at":   expiresAt,
+	}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("fail to update data exports: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *dataExportRepository) OpenFile(ctx context.Context, fileID primitive.ObjectID) (io.ReadCloser, int64, error) {
+	stream, err := r.files.OpenDownloadStream(fileID)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("fail to open data export file: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetReadDeadline(deadline)
+	}
+
+	return stream, stream.GetFile().Length, nil
+}
+
+// DeleteExpired removes the exports past their expiry together with their archives. A
+// TTL index would leave the GridFS files behind.
+func (r *dataExportRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return r.deleteMany(ctx, bson.M{
+		"expires_at": bson.M{"$lte": now},
+		"status":     bson.M{"$in": []string{models.DataExportReady, models.DataExportFailed}},
+	})
+}
+
+func (r *dataExportRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.deleteMany(ctx, bson.M{"user_id": userID})
+}
+
+// deleteMany removes each archive before its export, so a failure leaves at worst an
+// export pointing to a missing file rather than an unreachable file.
+func (r *dataExportRepository) deleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("fail to search data exports: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	var deleted int64
+	for cursor.Next(ctx) {
+		var export models.DataExport
+		if err := cursor.Decode(&export); err != nil {
+			return deleted, fmt.Errorf("fail to decode data export: %w", err)
+		}
+
+		if export.FileID != nil {
+			err := r.files.DeleteContext(ctx, *export.FileID)
+			if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+				return deleted, fmt.Errorf("fail to delete data export file: %w", err)
+			}
+		}
+
+		if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": export.ID}); err != nil {
+			return deleted, fmt.Errorf("fail to delete data export: %w", err)
+		}
+
+		deleted++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return deleted, fmt.Errorf("fail to search data exports: %w", err)
+	}
+
+	return deleted, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isDuplicateKeyOn reports whether err is a duplicate key error raised by the named index.
+// The server names the index in the message, e.g. "E11000 duplicate key error collection:
+// db.users index: email_1 dup key: ...".
+func isDuplicateKeyOn(err error, index string) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "index: "+index+" ")
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LockoutEventRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, event *models.LockoutEvent) (*models.LockoutEvent, error)
+	GetUnseenByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.LockoutEvent, error)
+	MarkSeenByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type lockoutEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLockoutEventRepository(db *mongo.Database) LockoutEventRepository {
+	return &lockoutEventRepository{
+		collection: db.Collection("lockout_events"),
+	}
+}
+
+func (r *lockoutEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "seen", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create lockout_events indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *lockoutEventRepository) Create(ctx context.Context, event *models.LockoutEvent) (*models.LockoutEvent, error) {
+	event.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	event.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, event); err != nil {
+		return nil, fmt.Errorf("Error the save lockout event in database %w", err)
+	}
+
+	return event, nil
+}
+
+func (r *lockoutEventRepository) GetUnseenByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.LockoutEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID, "seen": false}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("fail to list lockout events: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	events := []models.LockoutEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("fail to list lockout events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *lockoutEventRepository) MarkSeenByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	base := bson.D{{Key: "$set", Value: bson.D{{Key: "seen", Value: true}}}}
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userID, "seen": false}, base)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *lockoutEventRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
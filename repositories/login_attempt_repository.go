@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	GetById(ctx context.Context, key string) (*models.LoginAttempt, error)
+	IncrementFailures(ctx context.Context, key string, window time.Duration) (*models.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+type loginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLoginAttemptRepository(db *mongo.Database) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		collection: db.Collection("login_attempts"),
+	}
+}
+
+func (r *loginAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create login_attempts indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginAttemptRepository) GetById(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&attempt)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to search login attempts: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) IncrementFailures(ctx context.Context, key string, window time.Duration) (*models.LoginAttempt, error) {
+	now := time.Now()
+	base := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "failures", Value: 1}}},
+		{Key: "$set", Value: bson.D{{Key: "last_failure_at", Value: now}}},
+		{Key: "$max", Value: bson.D{{Key: "expires_at", Value: now.Add(window)}}},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var attempt models.LoginAttempt
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, base, opts).Decode(&attempt); err != nil {
+		return nil, fmt.Errorf("fail to register login failure: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+// Lock also pushes expires_at to until when it is earlier, so the TTL index cannot drop the
+// document while the lock is still running.
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "locked_until", Value: until}}},
+		{Key: "$max", Value: bson.D{{Key: "expires_at", Value: until}}},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": key}, base); err != nil {
+		return fmt.Errorf("fail to lock login: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginAttemptRepository) Delete(ctx context.Context, key string) error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("fail to reset login attempts: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OAuthClientRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, client *models.OAuthClient) (*models.OAuthClient, error)
+	GetByClientId(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	GetAllByOwnerId(ctx context.Context, ownerID primitive.ObjectID) ([]models.OAuthClient, error)
+	Delete(ctx context.Context, ownerID primitive.ObjectID, clientID string) error
+}
+
+type oauthClientRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOAuthClientRepository(db *mongo.Database) OAuthClientRepository {
+	return &oauthClientRepository{
+		collection: db.Collection("oauth_clients"),
+	}
+}
+
+func (r *oauthClientRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "client_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create oauth_clients indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *models.OAuthClient) (*models.OAuthClient, error) {
+	client.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	client.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, client); err != nil {
+		return nil, fmt.Errorf("Error the save oauth client in database %w", err)
+	}
+
+	return client, nil
+}
+
+func (r *oauthClientRepository) GetByClientId(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+
+	err := r.collection.FindOne(ctx, bson.M{"client_id": clientID}).Decode(&client)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to search oauth client: %w", err)
+	}
+
+	return &client, nil
+}
+
+func (r *oauthClientRepository) GetAllByOwnerId(ctx context.Context, ownerID primitive.ObjectID) ([]models.OAuthClient, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list oauth clients: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	clients := []models.OAuthClient{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, fmt.Errorf("Fail the to list oauth clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (r *oauthClientRepository) Delete(ctx context.Context, ownerID primitive.ObjectID, clientID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"client_id": clientID, "owner_id": ownerID})
+	if err != nil {
+		return fmt.Errorf("fail to delete oauth client: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound("OAuth client not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OAuthCodeRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, code *models.OAuthAuthorizationCode) (*models.OAuthAuthorizationCode, error)
+	Consume(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error)
+}
+
+type oauthCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOAuthCodeRepository(db *mongo.Database) OAuthCodeRepository {
+	return &oauthCodeRepository{
+		collection: db.Collection("oauth_codes"),
+	}
+}
+
+func (r *oauthCodeRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create oauth_codes indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oauthCodeRepository) Create(ctx context.Context, code *models.OAuthAuthorizationCode) (*models.OAuthAuthorizationCode, error) {
+	code.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	code.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, code); err != nil {
+		return nil, fmt.Errorf("Error the save authorization code in database %w", err)
+	}
+
+	return code, nil
+}
+
+// Consume deletes the code while reading it, so each code can be exchanged only once.
+func (r *oauthCodeRepository) Consume(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	filter := bson.M{
+		"code_hash":  codeHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var code models.OAuthAuthorizationCode
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&code)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to consume authorization code: %w", err)
+	}
+
+	return &code, nil
+}
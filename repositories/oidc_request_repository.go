@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OIDCRequestRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, request *models.OIDCAuthRequest) (*models.OIDCAuthRequest, error)
+	Consume(ctx context.Context, stateHash string) (*models.OIDCAuthRequest, error)
+}
+
+type oidcRequestRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOIDCRequestRepository(db *mongo.Database) OIDCRequestRepository {
+	return &oidcRequestRepository{
+		collection: db.Collection("oidc_requests"),
+	}
+}
+
+func (r *oidcRequestRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create oidc_requests indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oidcRequestRepository) Create(ctx context.Context, request *models.OIDCAuthRequest) (*models.OIDCAuthRequest, error) {
+	request.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	request.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, request); err != nil {
+		return nil, fmt.Errorf("Error the save OIDC request in database %w", err)
+	}
+
+	return request, nil
+}
+
+// Consume deletes the request while reading it, so a state can only be used once.
+func (r *oidcRequestRepository) Consume(ctx context.Context, stateHash string) (*models.OIDCAuthRequest, error) {
+	filter := bson.M{
+		"state_hash": stateHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var request models.OIDCAuthRequest
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&request)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to consume OIDC request: %w", err)
+	}
+
+	return &request, nil
+}
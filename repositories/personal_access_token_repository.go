@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lastUsedPrecision bounds how often last_used_at is written for a busy token.
+const lastUsedPrecision = time.Minute
+
+type PersonalAccessTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.PersonalAccessToken, error)
+	Touch(ctx context.Context, id primitive.ObjectID) error
+	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type personalAccessTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalAccessTokenRepository(db *mongo.Database) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{
+		collection: db.Collection("personal_access_tokens"),
+	}
+}
+
+func (r *personalAccessTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create personal_access_tokens indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) (*models.PersonalAccessToken, error) {
+	token.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	token.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, token); err != nil {
+		return nil, fmt.Errorf("Error the save personal access token in database %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *personalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to search personal access token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.PersonalAccessToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list personal access tokens: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	tokens := []models.PersonalAccessToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("Fail the to list personal access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepository) Touch(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"last_used_at": bson.M{"$exists": false}},
+			bson.M{"last_used_at": bson.M{"$lt": now.Add(-lastUsedPrecision)}},
+		},
+	}
+
+	base := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: now}}}}
+	if _, err := r.collection.UpdateOne(ctx, filter, base); err != nil {
+		return fmt.Errorf("fail to update personal access token usage: %w", err)
+	}
+
+	return nil
+}
+
+func (r *personalAccessTokenRepository) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("fail to delete personal access token: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound("Personal access token not found")
+	}
+
+	return nil
+}
+
+func (r *personalAccessTokenRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevokedTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Add(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time) error
+	Exists(ctx context.Context, jti string) (bool, error)
+}
+
+type revokedTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRevokedTokenRepository(db *mongo.Database) RevokedTokenRepository {
+	return &revokedTokenRepository{
+		collection: db.Collection("revoked_tokens"),
+	}
+}
+
+func (r *revokedTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create revoked_tokens indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *revokedTokenRepository) Add(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time) error {
+	if jti == "" {
+		return apperr.Validation("Token id is required")
+	}
+
+	now := time.Now()
+	revoked := models.RevokedToken{
+		ID:        jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: &now,
+	}
+
+	if _, err := r.collection.InsertOne(ctx, revoked); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *revokedTokenRepository) Exists(ctx context.Context, jti string) (bool, error) {
+	opts := options.FindOne().SetProjection(bson.M{"_id": 1})
+
+	var result struct {
+		ID string `bson:"_id"`
+	}
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": jti}, opts).Decode(&result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("fail to check if token is revoked: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SecurityEventRepository is append-only: events are never edited, and only removed with
+// the account they belong to when it is purged.
+type SecurityEventRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, event *models.SecurityEvent) (*models.SecurityEvent, error)
+	GetAll(ctx context.Context, userID *primitive.ObjectID, eventType string, email string, ip string, page, pageSize int) ([]models.SecurityEvent, int64, error)
+	ForEachByUserId(ctx context.Context, userID primitive.ObjectID, fn func(event *models.SecurityEvent) error) error
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type securityEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSecurityEventRepository(db *mongo.Database) SecurityEventRepository {
+	return &securityEventRepository{
+		collection: db.Collection("security_events"),
+	}
+}
+
+func (r *securityEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ip", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create security_events indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *securityEventRepository) Create(ctx context.Context, event *models.SecurityEvent) (*models.SecurityEvent, error) {
+	event.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	event.CreatedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, event); err != nil {
+		return nil, fmt.Errorf("Error the save security event in database %w", err)
+	}
+
+	return event, nil
+}
+
+func (r *securityEventRepository) GetAll(
+	ctx context.Context,
+	userID *primitive.ObjectID,
+	eventType string,
+	email string,
+	ip string,
+	page, pageSize int,
+) ([]models.SecurityEvent, int64, error) {
+	filter := bson.M{}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+	if eventType != "" {
+		filter["type"] = eventType
+	}
+	if email != "" {
+		filter["email"] = email
+	}
+	if ip != "" {
+		filter["ip"] = ip
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	events := []models.SecurityEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// ForEachByUserId calls fn with every event of the user, oldest first. It stops at the
+// first error fn returns.
+func (r *securityEventRepository) ForEachByUserId(ctx context.Context, userID primitive.ObjectID, fn func(event *models.SecurityEvent) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return fmt.Errorf("fail to search security events: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.SecurityEvent
+		if err := cursor.Decode(&event); err != nil {
+			return fmt.Errorf("fail to decode security event: %w", err)
+		}
+
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("fail to search security events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *securityEventRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("fail to delete security events: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, session *models.Session) (*models.Session, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error)
+	Rotate(ctx context.Context, id primitive.ObjectID, currentHash string, nextHash string, accessTokenID string, expiresAt time.Time) (*models.Session, error)
+	Touch(ctx context.Context, id primitive.ObjectID) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	DeleteAllByUserIdExcept(ctx context.Context, userID primitive.ObjectID, keepID primitive.ObjectID) (int64, error)
+	GetAllByClientId(ctx context.Context, clientID string) ([]models.Session, error)
+	DeleteAllByClientId(ctx context.Context, clientID string) (int64, error)
+}
+
+type sessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *mongo.Database) SessionRepository {
+	return &sessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+func (r *sessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "client_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create sessions indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+
+	session.CreatedAt = &now
+	session.LastUsedAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, session); err != nil {
+		return nil, fmt.Errorf("Error the save session in database %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) GetById(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Fail the to search session by id: %w", err)
+	}
+
+	return &session, nil
+}
+
+func (r *sessionRepository) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list sessions: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("Fail the to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, currentHash string, nextHash string, accessTokenID string, expiresAt time.Time) (*models.Session, error) {
+	filter := bson.M{"_id": id, "refresh_token_hash": currentHash}
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "refresh_token_hash", Value: nextHash},
+			{Key: "access_token_id", Value: accessTokenID},
+			{Key: "last_used_at", Value: time.Now()},
+			{Key: "expires_at", Value: expiresAt},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var sessionUpdated models.Session
+	err := r.collection.FindOneAndUpdate(ctx, filter, base, opts).Decode(&sessionUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to rotate session: %w", err)
+	}
+
+	return &sessionUpdated, nil
+}
+
+// Touch records that the session's access token was used. Like personal access tokens it
+// writes at most once per lastUsedPrecision.
+func (r *sessionRepository) Touch(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":          id,
+		"last_used_at": bson.M{"$lt": now.Add(-lastUsedPrecision)},
+	}
+
+	base := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: now}}}}
+	if _, err := r.collection.UpdateOne(ctx, filter, base); err != nil {
+		return fmt.Errorf("fail to update session usage: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("Error the delete session\nError: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound("Session not found")
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *sessionRepository) DeleteAllByUserIdExcept(ctx context.Context, userID primitive.ObjectID, keepID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"user_id": userID, "_id": bson.M{"$ne": keepID}}
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *sessionRepository) GetAllByClientId(ctx context.Context, clientID string) ([]models.Session, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"client_id": clientID})
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list sessions: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("Fail the to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) DeleteAllByClientId(ctx context.Context, clientID string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"client_id": clientID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 	taskdto "todolist-auth-fiber/dtos/taskDto"
 	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,13 +16,16 @@ import (
 )
 
 type TaskRepository interface {
-	GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, int, error)
-	Create(ctx context.Context, userID primitive.ObjectID, task models.Todo) (*models.Todo, int, error)
-	Delete(ctx context.Context, id primitive.ObjectID) (int, error)
-	ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, int, error)
-	Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, int, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, error)
+	Create(ctx context.Context, userID primitive.ObjectID, task models.Todo) (*models.Todo, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, error)
 	GetAll(ctx context.Context,userID primitive.ObjectID,title string,done *bool,createdAtBefore, createdAtAfter time.Time,page, pageSize int) ([]models.Todo, int64, error)
 	DeleteAllByUserId(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	CountByUserId(ctx context.Context, userId primitive.ObjectID) (int64, int64, error)
+	ForEachByUserId(ctx context.Context, userId primitive.ObjectID, fn func(task *models.Todo) error) error
+	FindOrphaned(ctx context.Context, limit int) ([]models.OrphanedTasks, error)
 }
 
 type taskRepository struct {
@@ -34,23 +38,23 @@ func NewTaskRepository(db *mongo.Database) TaskRepository {
 	}
 }
 
-func (r *taskRepository) GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, int, error) {
+func (r *taskRepository) GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, error) {
 	var task models.Todo
 	filter := bson.M{"_id": id}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&task)
 	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, 404, nil
+		return nil, nil
 	}
 
 	if err != nil {
-		return nil, 500, fmt.Errorf("Error the get tasks by id! Error: %w", err)
+		return nil, fmt.Errorf("Error the get tasks by id! Error: %w", err)
 	}
 
-	return &task, 200, nil
+	return &task, nil
 }
 
-func (r *taskRepository) Create(ctx context.Context, userID primitive.ObjectID, task models.Todo) (*models.Todo, int, error) {
+func (r *taskRepository) Create(ctx context.Context, userID primitive.ObjectID, task models.Todo) (*models.Todo, error) {
 	task.ID = primitive.NewObjectID()
 	now := time.Now()
 
@@ -60,28 +64,28 @@ func (r *taskRepository) Create(ctx context.Context, userID primitive.ObjectID,
 	task.UpdatedAt = &now
 
 	if _, err := r.collection.InsertOne(ctx, task); err != nil {
-		return nil, 500, fmt.Errorf("Error the save task in database %w", err)
+		return nil, fmt.Errorf("Error the save task in database %w", err)
 	}
 
-	return &task, 201, nil
+	return &task, nil
 }
 
-func (r *taskRepository) Delete(ctx context.Context, id primitive.ObjectID) (int, error) {
+func (r *taskRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	filter := bson.M{"_id": id}
 	result, err := r.collection.DeleteOne(ctx, filter)
 
 	if err != nil {
-		return 500, fmt.Errorf("Error the delete task\nError: %w", err)
+		return fmt.Errorf("Error the delete task\nError: %w", err)
 	}
 
 	if result.DeletedCount == 0 {
-		return 500, errors.New("Task not deleted")
+		return apperr.NotFound("Task not found")
 	}
 
-	return 200, nil
+	return nil
 }
 
-func (r *taskRepository) ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, int, error) {
+func (r *taskRepository) ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, error) {
 	base := bson.D{
 		{Key: "$set", Value: bson.D{
 			{Key: "done", Value: !task.Done},
@@ -94,17 +98,17 @@ func (r *taskRepository) ChangeStatus(ctx context.Context, id primitive.ObjectID
 	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&taskUpdated)
 
 	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, 404, fmt.Errorf("Task not found")
+		return nil, apperr.NotFound("Task not found")
 	}
 
 	if err != nil {
-		return nil, 500, fmt.Errorf("Error the change status tasks by id!\nError: %w", err)
+		return nil, fmt.Errorf("Error the change status tasks by id!\nError: %w", err)
 	}
 
-	return &taskUpdated, 200, nil
+	return &taskUpdated, nil
 }
 
-func (r *taskRepository) Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, int, error) {
+func (r *taskRepository) Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, error) {
 	base := bson.D{
 		{Key: "$set", Value: bson.D{
 			{Key: "done", Value: dto.Done},
@@ -119,14 +123,14 @@ func (r *taskRepository) Update(ctx context.Context, id primitive.ObjectID, dto
 	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&taskUpdated)
 
 	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, 404, fmt.Errorf("Task not found")
+		return nil, apperr.NotFound("Task not found")
 	}
 
 	if err != nil {
-		return nil, 500, fmt.Errorf("Error the to update tasks by id!\nError: %w", err)
+		return nil, fmt.Errorf("Error the to update tasks by id!\nError: %w", err)
 	}
 
-	return &taskUpdated, 200, nil
+	return &taskUpdated, nil
 }
 
 func (r *taskRepository) GetAll(
@@ -195,4 +199,86 @@ func (r *taskRepository) DeleteAllByUserId(ctx context.Context, userId primitive
 		return 0, err
 	}
 	return result.DeletedCount, nil
-}
\ No newline at end of file
+}
+
+// CountByUserId returns the total number of tasks of a user and how many of them are done.
+func (r *taskRepository) CountByUserId(ctx context.Context, userId primitive.ObjectID) (int64, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userId})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	done, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userId, "done": true})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return total, done, nil
+}
+
+// ForEachByUserId calls fn with every task of a user, oldest first, without loading them
+// all in memory. It stops at the first error fn returns.
+func (r *taskRepository) ForEachByUserId(ctx context.Context, userId primitive.ObjectID, fn func(task *models.Todo) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		return fmt.Errorf("fail to search tasks: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var task models.Todo
+		if err := cursor.Decode(&task); err != nil {
+			return fmt.Errorf("fail to decode task: %w", err)
+		}
+
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("fail to search tasks: %w", err)
+	}
+
+	return nil
+}
+
+// FindOrphaned groups tasks by owner and keeps the owners missing from users. Grouping
+// first means one users lookup per owner rather than per task.
+func (r *taskRepository) FindOrphaned(ctx context.Context, limit int) ([]models.OrphanedTasks, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$user_id"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "oldest_created_at", Value: bson.D{{Key: "$min", Value: "$created_at"}}},
+			{Key: "newest_created_at", Value: bson.D{{Key: "$max", Value: "$created_at"}}},
+		}}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "users"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "owner"},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "owner", Value: bson.D{{Key: "$size", Value: 0}}}}}},
+		{{Key: "$project", Value: bson.D{{Key: "owner", Value: 0}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("fail to search orphaned tasks: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	orphans := []models.OrphanedTasks{}
+	if err := cursor.All(ctx, &orphans); err != nil {
+		return nil, fmt.Errorf("fail to search orphaned tasks: %w", err)
+	}
+
+	return orphans, nil
+}
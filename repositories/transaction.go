@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const (
+	maxTransactionAttempts = 5
+	transactionBackoff     = 50 * time.Millisecond
+
+	transientTransactionLabel = "TransientTransactionError"
+	unknownCommitResultLabel  = "UnknownTransactionCommitResult"
+)
+
+// Transactor runs a function inside a MongoDB multi-document transaction. Repository
+// calls made with the ctx passed to fn take part in the transaction. Transactions need
+// a replica set or a sharded cluster.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(db *mongo.Database) Transactor {
+	return &transactor{client: db.Client()}
+}
+
+// WithTransaction retries the whole transaction on TransientTransactionError and the
+// commit on UnknownTransactionCommitResult, with a growing backoff, up to
+// maxTransactionAttempts times. fn may run more than once and must not have side effects
+// outside the database.
+func (t *transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	opts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("fail to start mongo session: %w", err)
+	}
+	defer session.EndSession(context.Background())
+
+	var lastErr error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		lastErr = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+			if err := sc.StartTransaction(opts); err != nil {
+				return err
+			}
+
+			if err := fn(sc); err != nil {
+				_ = sc.AbortTransaction(context.Background())
+				return err
+			}
+
+			return commitWithRetry(sc)
+		})
+
+		if lastErr == nil || !hasErrorLabel(lastErr, transientTransactionLabel) {
+			return lastErr
+		}
+
+		if err := sleep(ctx, backoff(attempt)); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("transaction failed after %d attempts: %w", maxTransactionAttempts, lastErr)
+}
+
+func commitWithRetry(sc mongo.SessionContext) error {
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = sc.CommitTransaction(sc)
+		if err == nil || !hasErrorLabel(err, unknownCommitResultLabel) {
+			return err
+		}
+
+		if sleepErr := sleep(sc, backoff(attempt)); sleepErr != nil {
+			return sleepErr
+		}
+	}
+
+	return err
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
+
+func backoff(attempt int) time.Duration {
+	return transactionBackoff * time.Duration(1<<(attempt-1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UserIdentityRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Create(ctx context.Context, identity *models.UserIdentity) (*models.UserIdentity, error)
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.UserIdentity, error)
+	TouchLogin(ctx context.Context, id primitive.ObjectID) error
+	Delete(ctx context.Context, userID primitive.ObjectID, provider string) error
+	DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type userIdentityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUserIdentityRepository(db *mongo.Database) UserIdentityRepository {
+	return &userIdentityRepository{
+		collection: db.Collection("user_identities"),
+	}
+}
+
+// EnsureIndexes allows one account per provider subject and one subject per provider per account.
+func (r *userIdentityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("fail to create user_identities indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) (*models.UserIdentity, error) {
+	identity.ID = primitive.NewObjectID()
+	now := time.Now()
+
+	identity.CreatedAt = &now
+	identity.LastLoginAt = &now
+
+	if _, err := r.collection.InsertOne(ctx, identity); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, apperr.Conflict(fmt.Sprintf("This %s account is already linked", identity.Provider))
+		}
+		return nil, fmt.Errorf("Error the save identity in database %w", err)
+	}
+
+	return identity, nil
+}
+
+func (r *userIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to search identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.UserIdentity, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list identities: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	identities := []models.UserIdentity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, fmt.Errorf("Fail the to list identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+func (r *userIdentityRepository) TouchLogin(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"last_login_at": time.Now()}}
+
+	if _, err := r.collection.UpdateByID(ctx, id, update); err != nil {
+		return fmt.Errorf("fail to update identity: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userIdentityRepository) Delete(ctx context.Context, userID primitive.ObjectID, provider string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID, "provider": provider})
+	if err != nil {
+		return fmt.Errorf("fail to delete identity: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound(fmt.Sprintf("No %s account is linked", provider))
+	}
+
+	return nil
+}
+
+func (r *userIdentityRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
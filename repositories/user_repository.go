@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 	"todolist-auth-fiber/dtos/userDto"
 	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,15 +17,36 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	usersEmailIndex    = "email_1"
+	usersUsernameIndex = "username_1"
+)
+
 type UserRepository interface {
-	GetEmail(ctx context.Context, email string) (*models.User, int, error)
-	GetId(ctx context.Context, id primitive.ObjectID) (*models.User, int, error)
-	Save(ctx context.Context, user *models.User) (*models.User, int, error)
-	Delete(ctx context.Context, id primitive.ObjectID) (int, error)
-	Update(ctx context.Context, id primitive.ObjectID, update userDto.UpdateUserDTO) (*models.User, uint, error)
-	ExistsByEmail(ctx context.Context, email string) (bool, int, error)
-	ExistsByUserName(ctx context.Context, username string) (bool, int, error)
-	SetRefreshToken(ctx context.Context, id primitive.ObjectID, refreshToken string) (*models.User, int, error)
+	EnsureIndexes(ctx context.Context) error
+	GetEmail(ctx context.Context, email string) (*models.User, error)
+	GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	Save(ctx context.Context, user *models.User) (*models.User, error)
+	Update(ctx context.Context, id primitive.ObjectID, update userDto.UpdateUserDTO) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsByUserName(ctx context.Context, username string) (bool, error)
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) (*models.User, error)
+	ChangeEmail(ctx context.Context, id primitive.ObjectID, email string) (*models.User, error)
+	UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) (*models.User, error)
+	SetPasswordHash(ctx context.Context, id primitive.ObjectID, currentHash string, passwordHash string) error
+	SetTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error
+	EnableTOTP(ctx context.Context, id primitive.ObjectID, counter int64, recoveryCodes []string) error
+	DisableTOTP(ctx context.Context, id primitive.ObjectID) error
+	UseTOTPCounter(ctx context.Context, id primitive.ObjectID, counter int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error)
+	Search(ctx context.Context, query string, page, pageSize int) ([]models.User, int64, error)
+	SetDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) (*models.User, error)
+	SetRole(ctx context.Context, id primitive.ObjectID, role string) (*models.User, error)
+	SetRoleByEmail(ctx context.Context, email string, role string) error
+	MarkPendingDeletion(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) (*models.User, error)
+	RestorePendingDeletion(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetAllDueForPurge(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	DeleteIfDueForPurge(ctx context.Context, id primitive.ObjectID, before time.Time) (bool, error)
 }
 
 type userRepository struct {
@@ -35,38 +59,38 @@ func NewUserRepository(db *mongo.Database) UserRepository {
 	}
 }
 
-func (u *userRepository) GetEmail(ctx context.Context, email string) (*models.User, int, error) {
+func (u *userRepository) GetEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	filter := bson.M{"email": email}
 
 	err := u.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, 404, nil
+			return nil, nil
 		}
-		return nil, 500, fmt.Errorf("Fail the to search user by email")
+		return nil, fmt.Errorf("Fail the to search user by email: %w", err)
 	}
 
-	return &user, 200, nil
+	return &user, nil
 }
 
-func (u *userRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, int, error) {
+func (u *userRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
 	var user models.User
 	filter := bson.M{"_id": id}
 
 	err := u.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, 404, nil
+			return nil, nil
 		}
 
-		return nil, 500, fmt.Errorf("Fail the to search user by id")
+		return nil, fmt.Errorf("Fail the to search user by id: %w", err)
 	}
 
-	return &user, 200, nil
+	return &user, nil
 }
 
-func (u *userRepository) Save(ctx context.Context, user *models.User) (*models.User, int, error) {
+func (u *userRepository) Save(ctx context.Context, user *models.User) (*models.User, error) {
 	user.ID = primitive.NewObjectID()
 	now := time.Now()
 
@@ -75,33 +99,21 @@ func (u *userRepository) Save(ctx context.Context, user *models.User) (*models.U
 
 	_, err := u.collection.InsertOne(ctx, user)
 	if err != nil {
-		return nil, 500, fmt.Errorf("Error the save user in database %w", err)
-	}
-
-	return user, 201, nil
-}
-
-func (u *userRepository) Delete(ctx context.Context, id primitive.ObjectID) (int, error) {
-	filter := bson.M{"_id": id}
-	result, err := u.collection.DeleteOne(ctx, filter)
-
-	if err != nil {
-		return 500, fmt.Errorf("Error the delete user\nError: %w", err)
-	}
-
-	if result.DeletedCount == 0 {
-		return 500, errors.New("User not deleted")
+		if conflict := userConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		return nil, fmt.Errorf("Error the save user in database %w", err)
 	}
 
-	return 200, nil
+	return user, nil
 }
 
-func (u *userRepository) Update(ctx context.Context, id primitive.ObjectID, update userDto.UpdateUserDTO) (*models.User, uint, error) {
+func (u *userRepository) Update(ctx context.Context, id primitive.ObjectID, update userDto.UpdateUserDTO) (*models.User, error) {
+	now := time.Now()
 	base := bson.D{
 		{Key: "$set", Value: bson.D{
 			{Key: "username", Value: update.Username},
-			{Key: "password", Value: update.Password},
-			{Key: "updated_at", Value: time.Now()},
+			{Key: "updated_at", Value: now},
 		}},
 	}
 
@@ -111,15 +123,18 @@ func (u *userRepository) Update(ctx context.Context, id primitive.ObjectID, upda
 	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&updatedUser)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, 404, fmt.Errorf("User not found")
+			return nil, apperr.NotFound("User not found")
+		}
+		if conflict := userConflict(err); conflict != nil {
+			return nil, conflict
 		}
-		return nil, 500, fmt.Errorf("fail to update user: %w", err)
+		return nil, fmt.Errorf("fail to update user: %w", err)
 	}
 
-	return &updatedUser, 200, nil
+	return &updatedUser, nil
 }
 
-func (u *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, int, error) {
+func (u *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	filter := bson.M{"email": email}
 	opts := options.FindOne().SetProjection(bson.M{"_id": 1})
 
@@ -130,15 +145,15 @@ func (u *userRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 	err := u.collection.FindOne(ctx, filter, opts).Decode(&result)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return false, 404, nil
+			return false, nil
 		}
-		return false, 500, fmt.Errorf("fail to check if user exists by email: %w", err)
+		return false, fmt.Errorf("fail to check if user exists by email: %w", err)
 	}
 
-	return true, 200, nil
+	return true, nil
 }
 
-func (u *userRepository) ExistsByUserName(ctx context.Context, username string) (bool, int, error) {
+func (u *userRepository) ExistsByUserName(ctx context.Context, username string) (bool, error) {
 	filter := bson.M{"username": username}
 	opts := options.FindOne().SetProjection(bson.M{"_id": 1})
 
@@ -149,31 +164,449 @@ func (u *userRepository) ExistsByUserName(ctx context.Context, username string)
 	err := u.collection.FindOne(ctx, filter, opts).Decode(&result)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return false, 404, nil
+			return false, nil
+		}
+		return false, fmt.Errorf("fail to check if user exists by username: %w", err)
+	}
+
+	return true, nil
+}
+
+func (u *userRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) (*models.User, error) {
+	now := time.Now()
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "email_verified", Value: true},
+			{Key: "email_verified_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "email": email}, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.NotFound("User not found")
 		}
-		return false, 500, fmt.Errorf("fail to check if user exists by username: %w", err)
+		return nil, fmt.Errorf("fail to verify user email: %w", err)
 	}
 
-	return true, 200, nil
+	return &userUpdated, nil
 }
 
-func (u *userRepository) SetRefreshToken(ctx context.Context, id primitive.ObjectID, refreshToken string) (*models.User, int, error) {
+// ChangeEmail stores an address that was confirmed through a link sent to it, so it is verified too.
+func (u *userRepository) ChangeEmail(ctx context.Context, id primitive.ObjectID, email string) (*models.User, error) {
+	now := time.Now()
 	base := bson.D{
 		{Key: "$set", Value: bson.D{
-			{Key: "refresh_token", Value: refreshToken},
+			{Key: "email", Value: email},
+			{Key: "email_verified", Value: true},
+			{Key: "email_verified_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.NotFound("User not found")
+		}
+		if conflict := userConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		return nil, fmt.Errorf("fail to change user email: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+func (u *userRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) (*models.User, error) {
+	now := time.Now()
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "password", Value: passwordHash},
+			{Key: "password_changed_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{
+			{Key: "credentials_version", Value: 1},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.NotFound("User not found")
+		}
+		return nil, fmt.Errorf("fail to update user password: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+// SetPasswordHash swaps the stored hash of an unchanged password, so it does not touch password_changed_at.
+func (u *userRepository) SetPasswordHash(ctx context.Context, id primitive.ObjectID, currentHash string, passwordHash string) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "password", Value: passwordHash},
+		}},
+	}
+
+	return u.updateOne(ctx, bson.M{"_id": id, "password": currentHash}, base)
+}
+
+func (u *userRepository) SetTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "totp_secret", Value: secret},
+			{Key: "totp_enabled", Value: false},
 			{Key: "updated_at", Value: time.Now()},
 		}},
 	}
 
+	return u.updateOne(ctx, bson.M{"_id": id}, base)
+}
+
+func (u *userRepository) EnableTOTP(ctx context.Context, id primitive.ObjectID, counter int64, recoveryCodes []string) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "totp_enabled", Value: true},
+			{Key: "totp_last_counter", Value: counter},
+			{Key: "recovery_codes", Value: recoveryCodes},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	return u.updateOne(ctx, bson.M{"_id": id}, base)
+}
+
+func (u *userRepository) DisableTOTP(ctx context.Context, id primitive.ObjectID) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "totp_enabled", Value: false},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "totp_secret", Value: ""},
+			{Key: "totp_last_counter", Value: ""},
+			{Key: "recovery_codes", Value: ""},
+		}},
+	}
+
+	return u.updateOne(ctx, bson.M{"_id": id}, base)
+}
+
+// UseTOTPCounter only succeeds for a time step newer than the last accepted one, so a code cannot be replayed.
+func (u *userRepository) UseTOTPCounter(ctx context.Context, id primitive.ObjectID, counter int64) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"totp_last_counter": bson.M{"$lt": counter}},
+			bson.M{"totp_last_counter": bson.M{"$exists": false}},
+		},
+	}
+	base := bson.D{{Key: "$set", Value: bson.D{{Key: "totp_last_counter", Value: counter}}}}
+
+	result, err := u.collection.UpdateOne(ctx, filter, base)
+	if err != nil {
+		return false, fmt.Errorf("fail to update totp counter: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (u *userRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": id, "recovery_codes": codeHash}
+	base := bson.D{{Key: "$pull", Value: bson.D{{Key: "recovery_codes", Value: codeHash}}}}
+
+	result, err := u.collection.UpdateOne(ctx, filter, base)
+	if err != nil {
+		return false, fmt.Errorf("fail to use recovery code: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (u *userRepository) updateOne(ctx context.Context, filter bson.M, base bson.D) error {
+	result, err := u.collection.UpdateOne(ctx, filter, base)
+	if err != nil {
+		return fmt.Errorf("fail to update user: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("User not found")
+	}
+
+	return nil
+}
+
+// Search matches query against username and email, newest accounts first.
+func (u *userRepository) Search(ctx context.Context, query string, page, pageSize int) ([]models.User, int64, error) {
+	filter := bson.M{}
+	if query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(query), "$options": "i"}
+		filter["$or"] = bson.A{
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+		}
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := u.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := u.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// SetDisabled also bumps the credentials version, so tokens already issued stop working.
+func (u *userRepository) SetDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) (*models.User, error) {
+	now := time.Now()
+
+	var disabledAt interface{}
+	if disabled {
+		disabledAt = now
+	}
+
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "disabled", Value: disabled},
+			{Key: "disabled_at", Value: disabledAt},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{
+			{Key: "credentials_version", Value: 1},
+		}},
+	}
+
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var userUpdated models.User
 	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&userUpdated)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, 404, fmt.Errorf("User not found")
+			return nil, apperr.NotFound("User not found")
+		}
+		return nil, fmt.Errorf("fail to update user status: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+func (u *userRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) (*models.User, error) {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "role", Value: role},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.NotFound("User not found")
+		}
+		return nil, fmt.Errorf("fail to update user role: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+func (u *userRepository) SetRoleByEmail(ctx context.Context, email string, role string) error {
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "role", Value: role},
+		}},
+	}
+
+	return u.updateOne(ctx, bson.M{"email": email}, base)
+}
+
+// EnsureIndexes makes email and username unique, so two concurrent signups or email
+// changes cannot both pass the exists check and claim the same value.
+func (u *userRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := u.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName(usersEmailIndex).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName(usersUsernameIndex).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "purge_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return u.duplicatesError(ctx, err)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to create users indexes: %w", err)
+	}
+
+	return nil
+}
+
+// maxReportedDuplicates caps how many duplicated values duplicatesError lists per field.
+const maxReportedDuplicates = 20
+
+// duplicatesError names the emails and usernames shared by several accounts, which keep
+// the unique indexes from being built on a database that predates them.
+func (u *userRepository) duplicatesError(ctx context.Context, cause error) error {
+	found := []string{}
+	for _, field := range []string{"email", "username"} {
+		pipeline := mongo.Pipeline{
+			{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$" + field}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+			{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+			{{Key: "$limit", Value: maxReportedDuplicates}},
+		}
+
+		cursor, err := u.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return fmt.Errorf("fail to create users indexes: %w", cause)
+		}
+
+		var duplicates []struct {
+			Value interface{} `bson:"_id"`
+			Count int         `bson:"count"`
+		}
+		if err := cursor.All(ctx, &duplicates); err != nil {
+			return fmt.Errorf("fail to create users indexes: %w", cause)
+		}
+
+		for _, duplicate := range duplicates {
+			found = append(found, fmt.Sprintf("%s %v (%d accounts)", field, duplicate.Value, duplicate.Count))
+		}
+	}
+
+	if len(found) == 0 {
+		return fmt.Errorf("fail to create users indexes: %w", cause)
+	}
+
+	return fmt.Errorf("fail to create users indexes, merge or rename the accounts sharing %s: %w", strings.Join(found, ", "), cause)
+}
+
+// userConflict turns a duplicate key error on email or username into a Conflict, or
+// returns nil for any other error.
+func userConflict(err error) error {
+	switch {
+	case isDuplicateKeyOn(err, usersEmailIndex):
+		return apperr.Conflict("Email already in use")
+	case isDuplicateKeyOn(err, usersUsernameIndex):
+		return apperr.Conflict("Username already in use")
+	default:
+		return nil
+	}
+}
+
+// MarkPendingDeletion schedules the purge of the account and bumps the credentials version,
+// so tokens already issued stop working.
+func (u *userRepository) MarkPendingDeletion(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) (*models.User, error) {
+	now := time.Now()
+	base := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "deleted_at", Value: now},
+			{Key: "purge_at", Value: purgeAt},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{
+			{Key: "credentials_version", Value: 1},
+		}},
+	}
+
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, filter, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.Conflict("Account is already scheduled for deletion")
 		}
-		return nil, 500, fmt.Errorf("fail to update user: %w", err)
+		return nil, fmt.Errorf("fail to schedule account deletion: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+// RestorePendingDeletion only matches accounts whose purge date has not passed yet.
+func (u *userRepository) RestorePendingDeletion(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	base := bson.D{
+		{Key: "$unset", Value: bson.D{
+			{Key: "deleted_at", Value: ""},
+			{Key: "purge_at", Value: ""},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	filter := bson.M{"_id": id, "purge_at": bson.M{"$gt": time.Now()}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var userUpdated models.User
+	err := u.collection.FindOneAndUpdate(ctx, filter, base, opts).Decode(&userUpdated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperr.NotFound("No account pending deletion to recover")
+		}
+		return nil, fmt.Errorf("fail to recover account: %w", err)
+	}
+
+	return &userUpdated, nil
+}
+
+func (u *userRepository) GetAllDueForPurge(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "purge_at", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := u.collection.Find(ctx, bson.M{"purge_at": bson.M{"$lte": before}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Fail the to list accounts due for purge: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("Fail the to list accounts due for purge: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteIfDueForPurge re-checks the purge date in the delete itself, so an account
+// recovered after it was listed is kept.
+func (u *userRepository) DeleteIfDueForPurge(ctx context.Context, id primitive.ObjectID, before time.Time) (bool, error) {
+	result, err := u.collection.DeleteOne(ctx, bson.M{"_id": id, "purge_at": bson.M{"$lte": before}})
+	if err != nil {
+		return false, fmt.Errorf("Error the delete user\nError: %w", err)
 	}
 
-	return &userUpdated, 200, nil
+	return result.DeletedCount == 1, nil
 }
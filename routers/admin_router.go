@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+	"todolist-auth-fiber/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func AdminRouter(app *fiber.App, adminHandler handlers.AdminHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/admin", auth, middleware.RejectImpersonation())
+
+	usersRead := middleware.RequirePermission(models.PermissionUsersRead)
+	usersManage := middleware.RequirePermission(models.PermissionUsersManage)
+
+	router.Get("/users", usersRead, rate.GetRate(), adminHandler.GetUsers)
+	router.Get("/users/:id", usersRead, rate.GetRate(), adminHandler.GetUser)
+	router.Put("/users/:id/disable", usersManage, rate.UpdateRate(), adminHandler.DisableUser)
+	router.Put("/users/:id/enable", usersManage, rate.UpdateRate(), adminHandler.EnableUser)
+	router.Put("/users/:id/role", usersManage, rate.UpdateRate(), adminHandler.SetRole)
+	router.Post("/users/:id/impersonate", middleware.RequirePermission(models.PermissionImpersonate), rate.CustomRate(10, time.Minute), adminHandler.Impersonate)
+	router.Delete("/users/:id/sessions", middleware.RequirePermission(models.PermissionSessionsManage), rate.DeleteRate(), adminHandler.ForceLogout)
+	router.Get("/users/:id/tasks/count", middleware.RequirePermission(models.PermissionTasksStats), rate.GetRate(), adminHandler.TaskCounts)
+	router.Get("/consistency/orphaned-tasks", middleware.RequirePermission(models.PermissionTasksStats), rate.GetRate(), adminHandler.OrphanedTasks)
+	router.Get("/audit", middleware.RequirePermission(models.PermissionAuditRead), rate.GetRate(), adminHandler.GetAuditLogs)
+	router.Get("/security-events", middleware.RequirePermission(models.PermissionAuditRead), rate.GetRate(), adminHandler.GetSecurityEvents)
+}
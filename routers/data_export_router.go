@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DataExportRouter(app *fiber.App, dataExportHandler handlers.DataExportHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/exports")
+
+	router.Post("", auth, middleware.RejectImpersonation(), rate.CustomRate(3, time.Hour), dataExportHandler.Create)
+	router.Get("", auth, middleware.RejectImpersonation(), rate.GetRate(), dataExportHandler.GetAll)
+	router.Get("/:id", auth, middleware.RejectImpersonation(), rate.GetRate(), dataExportHandler.Get)
+	router.Get("/:id/download", rate.CustomRate(10, time.Minute), dataExportHandler.Download)
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func EmailRouter(app *fiber.App, emailHandler handlers.EmailHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/email")
+
+	router.Post("", auth, middleware.RejectImpersonation(), rate.CustomRate(3, time.Minute), emailHandler.RequestChange)
+	router.Get("/confirm", rate.CustomRate(20, 15*time.Second), emailHandler.ConfirmChange)
+	router.Get("/cancel", rate.CustomRate(20, 15*time.Second), emailHandler.CancelChange)
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func IdentityRouter(app *fiber.App, identityHandler handlers.IdentityHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/identities", auth, middleware.RejectImpersonation())
+
+	router.Get("", rate.GetRate(), identityHandler.GetAll)
+	router.Post("/:provider", rate.CustomRate(10, time.Minute), identityHandler.BeginLink)
+	router.Post("/:provider/callback", rate.CustomRate(10, time.Minute), identityHandler.Link)
+	router.Delete("/:provider", rate.DeleteRate(), identityHandler.Unlink)
+}
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func OAuthRouter(app *fiber.App, oauthHandler handlers.OAuthHandler, clientHandler handlers.OAuthClientHandler, auth fiber.Handler) {
+	router := app.Group("/oauth")
+
+	router.Get("/authorize", auth, middleware.RejectImpersonation(), rate.GetRate(), oauthHandler.Authorize)
+	router.Post("/authorize", auth, middleware.RejectImpersonation(), rate.CreateRate(), oauthHandler.Consent)
+	router.Post("/token", rate.CustomRate(30, time.Minute), oauthHandler.Token)
+	router.Post("/introspect", rate.CustomRate(120, time.Minute), oauthHandler.Introspect)
+	router.Post("/revoke", rate.CustomRate(30, time.Minute), oauthHandler.Revoke)
+
+	clients := app.Group("/api/v1/oauth/clients", auth, middleware.RejectImpersonation())
+
+	clients.Get("", rate.GetRate(), clientHandler.GetAll)
+	clients.Post("", rate.CreateRate(), clientHandler.Create)
+	clients.Delete("/:client_id", rate.DeleteRate(), clientHandler.Delete)
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PasswordRouter(app *fiber.App, passwordHandler handlers.PasswordHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/password")
+
+	router.Put("", auth, middleware.RejectImpersonation(), rate.CustomRate(5, time.Minute), passwordHandler.Change)
+	router.Post("/forgot", rate.CustomRate(5, time.Minute), passwordHandler.Forgot)
+	router.Post("/reset", rate.CustomRate(10, time.Minute), passwordHandler.Reset)
+}
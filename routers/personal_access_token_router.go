@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func PersonalAccessTokenRouter(app *fiber.App, tokenHandler handlers.PersonalAccessTokenHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/tokens", auth, middleware.RejectImpersonation())
+
+	router.Get("", rate.GetRate(), tokenHandler.GetAll)
+	router.Post("", rate.CreateRate(), tokenHandler.Create)
+	router.Delete("/:id", rate.DeleteRate(), tokenHandler.Revoke)
+}
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"todolist-auth-fiber/handlers"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SecurityEventRouter(app *fiber.App, securityEventHandler handlers.SecurityEventHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/security-events", auth)
+
+	router.Get("", rate.GetRate(), securityEventHandler.GetAll)
+}
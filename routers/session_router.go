@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SessionRouter(app *fiber.App, sessionHandler handlers.SessionHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/sessions", auth)
+
+	router.Get("", rate.GetRate(), sessionHandler.GetAll)
+	router.Delete("/:id", middleware.RejectImpersonation(), rate.DeleteRate(), sessionHandler.Revoke)
+	router.Delete("", middleware.RejectImpersonation(), rate.DeleteRate(), sessionHandler.RevokeOthers)
+}
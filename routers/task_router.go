@@ -2,18 +2,23 @@ package routers
 
 import (
 	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
 	rate "todolist-auth-fiber/middleware/rateLimiting"
+	"todolist-auth-fiber/models"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func TaskRouter(app *fiber.App, taskHandler handlers.TaskHandler) {
-	router := app.Group("/api/v1/tasks")
+func TaskRouter(app *fiber.App, taskHandler handlers.TaskHandler, auth fiber.Handler, verified fiber.Handler) {
+	router := app.Group("/api/v1/tasks", auth, verified)
 
-	router.Get("/:id", rate.GetRate(), taskHandler.GetById)
-	router.Post("", rate.CreateRate(), taskHandler.Create)
-	router.Delete("/:id", rate.DeleteRate(), taskHandler.Delete)
-	router.Put("/:id", rate.UpdateRate(), taskHandler.Update)
-	router.Put("/:id/status/done", rate.UpdateRate(), taskHandler.ChangeStatus)
-	router.Get("", rate.GetRate(), taskHandler.GetAll)
-}
\ No newline at end of file
+	read := middleware.RequireScope(models.ScopeTasksRead)
+	write := middleware.RequireScope(models.ScopeTasksWrite)
+
+	router.Get("/:id", read, rate.GetRate(), taskHandler.GetById)
+	router.Post("", write, rate.CreateRate(), taskHandler.Create)
+	router.Delete("/:id", write, rate.DeleteRate(), taskHandler.Delete)
+	router.Put("/:id", write, rate.UpdateRate(), taskHandler.Update)
+	router.Put("/:id/status/done", write, rate.UpdateRate(), taskHandler.ChangeStatus)
+	router.Get("", read, rate.GetRate(), taskHandler.GetAll)
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"time"
+	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TwoFactorRouter(app *fiber.App, twoFactorHandler handlers.TwoFactorHandler, auth fiber.Handler) {
+	router := app.Group("/api/v1/users/2fa", auth, middleware.RejectImpersonation())
+
+	router.Post("/enroll", rate.CustomRate(10, time.Minute), twoFactorHandler.Enroll)
+	router.Post("/activate", rate.CustomRate(10, time.Minute), twoFactorHandler.Activate)
+	router.Post("/disable", rate.CustomRate(10, time.Minute), twoFactorHandler.Disable)
+}
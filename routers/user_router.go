@@ -3,18 +3,31 @@ package routers
 import (
 	"time"
 	"todolist-auth-fiber/handlers"
+	"todolist-auth-fiber/middleware"
+	"todolist-auth-fiber/models"
 	rate "todolist-auth-fiber/middleware/rateLimiting"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func UserRouter(app *fiber.App, userHandler handlers.UserHandler)  {
+func UserRouter(app *fiber.App, userHandler handlers.UserHandler, auth fiber.Handler, tokenAuth fiber.Handler)  {
 	user := app.Group("/api/v1/users")
 
-	user.Get("", rate.GetRate(), userHandler.Me)
+	user.Get("", tokenAuth, middleware.RequireScope(models.ScopeAccountRead), rate.GetRate(), userHandler.Me)
 	user.Post("/register", rate.CreateRate(), userHandler.Create)
 	user.Post("/login", rate.CustomRate(50, 15 * time.Second), userHandler.Login)
-	user.Delete("", rate.DeleteRate(), userHandler.Delete)
-	user.Put("", rate.UpdateRate(), userHandler.Update)
-	user.Put("/revoke", rate.CustomRate(40, 10 * time.Second), userHandler.Revoke)
-}
\ No newline at end of file
+	user.Post("/login/magic", rate.CustomRate(5, time.Minute), userHandler.RequestMagicLink)
+	user.Post("/login/magic/redeem", rate.CustomRate(10, time.Minute), userHandler.RedeemMagicLink)
+	user.Get("/login/oidc", rate.GetRate(), userHandler.OIDCProviders)
+	user.Post("/login/oidc/:provider", rate.CustomRate(10, time.Minute), userHandler.BeginOIDCLogin)
+	user.Post("/login/oidc/:provider/callback", rate.CustomRate(10, time.Minute), userHandler.OIDCLogin)
+	user.Post("/login/2fa", rate.CustomRate(10, time.Minute), userHandler.LoginTwoFactor)
+	user.Delete("", auth, middleware.RejectImpersonation(), rate.DeleteRate(), userHandler.Delete)
+	user.Post("/recover", rate.CustomRate(5, time.Minute), userHandler.RequestRecovery)
+	user.Post("/recover/confirm", rate.CustomRate(10, time.Minute), userHandler.Recover)
+	user.Put("", auth, middleware.RejectImpersonation(), rate.UpdateRate(), userHandler.Update)
+	user.Put("/revoke", auth, middleware.RejectImpersonation(), rate.CustomRate(40, 10 * time.Second), userHandler.Revoke)
+	user.Post("/refresh", rate.CustomRate(30, 15 * time.Second), userHandler.Refresh)
+	user.Get("/verify-email", rate.CustomRate(20, 15 * time.Second), userHandler.VerifyEmail)
+	user.Post("/verify-email/resend", auth, middleware.RejectImpersonation(), rate.CustomRate(3, time.Minute), userHandler.ResendVerification)
+}
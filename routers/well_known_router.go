@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"todolist-auth-fiber/handlers"
+	rate "todolist-auth-fiber/middleware/rateLimiting"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WellKnownRouter(app *fiber.App, wellKnownHandler handlers.WellKnownHandler) {
+	router := app.Group("/.well-known")
+
+	router.Get("/jwks.json", rate.GetRate(), wellKnownHandler.JWKS)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+)
+
+type AccountDeletionService interface {
+	Request(ctx context.Context, user *models.User) (*models.User, error)
+	RequestRecovery(ctx context.Context, email string) error
+	Recover(ctx context.Context, token string) (*models.User, error)
+}
+
+type accountDeletionService struct {
+	userRepo       repository.UserRepository
+	actionTokens   repository.ActionTokenRepository
+	sessionService SessionService
+	mailer         mailer.Mailer
+	frontendURL    string
+	gracePeriod    time.Duration
+}
+
+func NewAccountDeletionService(
+	userRepo repository.UserRepository,
+	actionTokens repository.ActionTokenRepository,
+	sessionService SessionService,
+	mailer mailer.Mailer,
+	frontendURL string,
+	gracePeriod time.Duration,
+) AccountDeletionService {
+	return &accountDeletionService{
+		userRepo:       userRepo,
+		actionTokens:   actionTokens,
+		sessionService: sessionService,
+		mailer:         mailer,
+		frontendURL:    frontendURL,
+		gracePeriod:    gracePeriod,
+	}
+}
+
+// Request schedules the account for purge at the end of the grace period, logs it out
+// everywhere and mails a recovery link. Nothing is deleted until the purger runs.
+func (s *accountDeletionService) Request(ctx context.Context, user *models.User) (*models.User, error) {
+	pending, err := s.userRepo.MarkPendingDeletion(ctx, user.ID, time.Now().Add(s.gracePeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.sessionService.RevokeAll(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendRecoveryLink(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// RequestRecovery mails a new recovery link. It answers the same way whether or not the
+// email belongs to an account pending deletion.
+func (s *accountDeletionService) RequestRecovery(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil || !user.PendingDeletion() || user.PurgeAt == nil || !user.PurgeAt.After(time.Now()) {
+		return nil
+	}
+
+	return s.sendRecoveryLink(ctx, user)
+}
+
+func (s *accountDeletionService) Recover(ctx context.Context, token string) (*models.User, error) {
+	recovery, err := s.actionTokens.Consume(ctx, models.ActionAccountRecovery, crypto.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if recovery == nil {
+		return nil, apperr.Validation("Recovery link invalid or expired")
+	}
+
+	user, err := s.userRepo.RestorePendingDeletion(ctx, recovery.UserID)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			return nil, apperr.Validation("Recovery link invalid or expired")
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// sendRecoveryLink replaces any previous link with one that expires with the grace period.
+func (s *accountDeletionService) sendRecoveryLink(ctx context.Context, user *models.User) error {
+	if _, err := s.actionTokens.DeleteAllByUserIdAndPurpose(ctx, user.ID, models.ActionAccountRecovery); err != nil {
+		return err
+	}
+
+	token, err := crypto.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.actionTokens.Create(ctx, &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.ActionAccountRecovery,
+		TokenHash: crypto.HashToken(token),
+		ExpiresAt: *user.PurgeAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your account will be deleted",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Your account and all its data will be deleted on " + user.PurgeAt.UTC().Format(time.RFC1123) + ".\n" +
+			"Changed your mind? Open the link below before then to keep your account:\n\n" +
+			s.frontendURL + "/recover-account?token=" + url.QueryEscape(token),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("failed to send account recovery email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/mailer"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeDeletionUserRepository applies the same filters as the mongo queries: an account
+// can only be scheduled once, and only restored before its purge date.
+type fakeDeletionUserRepository struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r *fakeDeletionUserRepository) GetEmail(ctx context.Context, email string) (*models.User, error) {
+	if email != r.user.Email {
+		return nil, nil
+	}
+	copied := *r.user
+	return &copied, nil
+}
+
+func (r *fakeDeletionUserRepository) MarkPendingDeletion(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) (*models.User, error) {
+	if r.user.DeletedAt != nil {
+		return nil, apperr.Conflict("Account is already scheduled for deletion")
+	}
+	now := time.Now()
+	r.user.DeletedAt = &now
+	r.user.PurgeAt = &purgeAt
+	copied := *r.user
+	return &copied, nil
+}
+
+func (r *fakeDeletionUserRepository) RestorePendingDeletion(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	if r.user.PurgeAt == nil || !r.user.PurgeAt.After(time.Now()) {
+		return nil, apperr.NotFound("No account pending deletion to recover")
+	}
+	r.user.DeletedAt = nil
+	r.user.PurgeAt = nil
+	copied := *r.user
+	return &copied, nil
+}
+
+type deletionFixture struct {
+	service  AccountDeletionService
+	users    *fakeDeletionUserRepository
+	sessions *fakeSessionRepository
+	mail     *fakeMailer
+}
+
+func newDeletionFixture(user *models.User, gracePeriod time.Duration) deletionFixture {
+	f := deletionFixture{
+		users:    &fakeDeletionUserRepository{user: user},
+		sessions: newFakeSessionRepository(models.Session{ID: primitive.NewObjectID(), UserID: user.ID, AccessTokenID: "session-jti"}),
+		mail:     &fakeMailer{sent: make(chan mailer.Message, 10)},
+	}
+
+	f.service = NewAccountDeletionService(
+		f.users,
+		&fakeActionTokenRepository{tokens: map[string]models.ActionToken{}},
+		NewSessionService(f.sessions, &fakeRevokedTokenRepository{revoked: map[string]bool{}}),
+		f.mail,
+		"https://app.example.com",
+		gracePeriod,
+	)
+
+	return f
+}
+
+func recoveryToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+
+	_, token, found := strings.Cut(msg.Body, "/recover-account?token=")
+	if !found {
+		t.Fatalf("email has no recovery link: %q", msg.Body)
+	}
+	return token
+}
+
+func TestAccountDeletionServiceGracePeriod(t *testing.T) {
+	const gracePeriod = 14 * 24 * time.Hour
+
+	t.Run("restore within the grace period", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Username: "ada"}
+		f := newDeletionFixture(user, gracePeriod)
+
+		pending, err := f.service.Request(context.Background(), user)
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		if !pending.PendingDeletion() {
+			t.Fatalf("Request() did not mark the account pending deletion")
+		}
+		if until := time.Until(*pending.PurgeAt); until < gracePeriod-time.Minute || until > gracePeriod {
+			t.Fatalf("purge scheduled in %v, want %v", until, gracePeriod)
+		}
+		if len(f.sessions.sessions) != 0 {
+			t.Fatalf("%d sessions kept, want every session revoked", len(f.sessions.sessions))
+		}
+
+		if _, err := f.service.Request(context.Background(), user); !apperr.Is(err, apperr.KindConflict) {
+			t.Fatalf("second Request() error = %v, want Conflict", err)
+		}
+
+		token := recoveryToken(t, f.mail.next(t))
+
+		restored, err := f.service.Recover(context.Background(), token)
+		if err != nil {
+			t.Fatalf("Recover() error = %v", err)
+		}
+		if restored.PendingDeletion() || restored.PurgeAt != nil {
+			t.Fatalf("Recover() = %+v, want the deletion cancelled", restored)
+		}
+
+		if _, err := f.service.Recover(context.Background(), token); !apperr.Is(err, apperr.KindValidation) {
+			t.Fatalf("second Recover() error = %v, want Validation", err)
+		}
+	})
+
+	t.Run("no restore once the purge date passed", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Email: "grace@example.com", Username: "grace"}
+		f := newDeletionFixture(user, gracePeriod)
+
+		if _, err := f.service.Request(context.Background(), user); err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		token := recoveryToken(t, f.mail.next(t))
+
+		passed := time.Now().Add(-time.Minute)
+		f.users.user.PurgeAt = &passed
+
+		if _, err := f.service.Recover(context.Background(), token); !apperr.Is(err, apperr.KindValidation) {
+			t.Fatalf("Recover() error = %v, want Validation", err)
+		}
+		if !f.users.user.PendingDeletion() {
+			t.Fatalf("account was restored after its purge date")
+		}
+
+		// A new link is not sent either: the account is waiting for the purger.
+		if err := f.service.RequestRecovery(context.Background(), user.Email); err != nil {
+			t.Fatalf("RequestRecovery() error = %v", err)
+		}
+		select {
+		case msg := <-f.mail.sent:
+			t.Fatalf("recovery email sent after the purge date: %q", msg.Subject)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("recovery requests for active accounts send nothing", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Email: "linus@example.com", Username: "linus"}
+		f := newDeletionFixture(user, gracePeriod)
+
+		for _, email := range []string{user.Email, "nobody@example.com"} {
+			if err := f.service.RequestRecovery(context.Background(), email); err != nil {
+				t.Fatalf("RequestRecovery(%q) error = %v", email, err)
+			}
+		}
+		select {
+		case msg := <-f.mail.sent:
+			t.Fatalf("recovery email sent: %q", msg.Subject)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
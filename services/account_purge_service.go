@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+)
+
+// purgeBatchSize bounds how many accounts one purge run deletes.
+const purgeBatchSize = 100
+
+type AccountPurgeService interface {
+	PurgeDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type accountPurgeService struct {
+	transactor         repository.Transactor
+	userRepo           repository.UserRepository
+	taskRepo           repository.TaskRepository
+	sessionService     SessionService
+	personalTokens     repository.PersonalAccessTokenRepository
+	actionTokens       repository.ActionTokenRepository
+	lockoutEvents      repository.LockoutEventRepository
+	securityEvents     repository.SecurityEventRepository
+	identities         repository.UserIdentityRepository
+	oauthClientService OAuthClientService
+	dataExports        repository.DataExportRepository
+}
+
+func NewAccountPurgeService(
+	transactor repository.Transactor,
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	sessionService SessionService,
+	personalTokens repository.PersonalAccessTokenRepository,
+	actionTokens repository.ActionTokenRepository,
+	lockoutEvents repository.LockoutEventRepository,
+	securityEvents repository.SecurityEventRepository,
+	identities repository.UserIdentityRepository,
+	oauthClientService OAuthClientService,
+	dataExports repository.DataExportRepository,
+) AccountPurgeService {
+	return &accountPurgeService{
+		transactor:         transactor,
+		userRepo:           userRepo,
+		taskRepo:           taskRepo,
+		sessionService:     sessionService,
+		personalTokens:     personalTokens,
+		actionTokens:       actionTokens,
+		lockoutEvents:      lockoutEvents,
+		securityEvents:     securityEvents,
+		identities:         identities,
+		oauthClientService: oauthClientService,
+		dataExports:        dataExports,
+	}
+}
+
+// Run purges due accounts every interval until ctx is done.
+func (s *accountPurgeService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeDue(ctx)
+		if err != nil {
+			log.Printf("account purge failed: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("account purge: deleted %d accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue hard-deletes the accounts whose grace period ended, with everything they own.
+// Audit logs are kept.
+func (s *accountPurgeService) PurgeDue(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	users, err := s.userRepo.GetAllDueForPurge(ctx, now, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// One account that keeps failing must not block the accounts queued behind it, so
+	// failures are logged and the rest of the batch still runs.
+	purged := 0
+	var errs []error
+	for i := range users {
+		deleted, err := s.purge(ctx, &users[i], now)
+		if err != nil {
+			log.Printf("account purge: user %s: %v", users[i].ID.Hex(), err)
+			errs = append(errs, fmt.Errorf("purge of user %s: %w", users[i].ID.Hex(), err))
+			continue
+		}
+		if deleted {
+			purged++
+		}
+	}
+
+	return purged, errors.Join(errs...)
+}
+
+// purge tears the account down in one transaction, so a crash or a failed delete never
+// leaves a user without its data or data without its user. The user delete is guarded by
+// the purge date, so an account recovered in the meantime is left alone.
+func (s *accountPurgeService) purge(ctx context.Context, user *models.User, now time.Time) (bool, error) {
+	var deleted bool
+
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = s.userRepo.DeleteIfDueForPurge(ctx, user.ID, now)
+		if err != nil || !deleted {
+			return err
+		}
+
+		if _, err := s.taskRepo.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.sessionService.RevokeAll(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.personalTokens.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.actionTokens.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.lockoutEvents.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.securityEvents.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if _, err := s.identities.DeleteAllByUserId(ctx, user.ID); err != nil {
+			return err
+		}
+
+		clients, err := s.oauthClientService.GetAllByOwnerId(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, client := range clients {
+			if err := s.oauthClientService.Delete(ctx, user.ID, client.ClientID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// GridFS deletes cannot join the transaction. Leftovers are retried on the next run
+	// of the export cleanup once they expire.
+	if deleted {
+		if _, err := s.dataExports.DeleteAllByUserId(ctx, user.ID); err != nil {
+			log.Printf("account purge: data exports of user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	return deleted, nil
+}
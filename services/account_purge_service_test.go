@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakePurgeUserRepository holds the accounts pending deletion in purge_at order.
+type fakePurgeUserRepository struct {
+	repository.UserRepository
+	pending []models.User
+	failing map[primitive.ObjectID]bool
+	deleted map[primitive.ObjectID]bool
+}
+
+func (r *fakePurgeUserRepository) GetAllDueForPurge(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	due := []models.User{}
+	for _, user := range r.pending {
+		if !r.deleted[user.ID] && user.PurgeAt != nil && !user.PurgeAt.After(before) && len(due) < limit {
+			due = append(due, user)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakePurgeUserRepository) DeleteIfDueForPurge(ctx context.Context, id primitive.ObjectID, before time.Time) (bool, error) {
+	if r.failing[id] {
+		return false, errors.New("write conflict")
+	}
+	r.deleted[id] = true
+	return true, nil
+}
+
+// ownedRecords counts the DeleteAllByUserId calls of the repositories a purge empties.
+type ownedRecords map[primitive.ObjectID]int
+
+func (o ownedRecords) deleteAll(userID primitive.ObjectID) (int64, error) {
+	o[userID]++
+	return 1, nil
+}
+
+type fakePurgeTaskRepository struct {
+	repository.TaskRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeTaskRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgePersonalAccessTokenRepository struct {
+	repository.PersonalAccessTokenRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgePersonalAccessTokenRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeActionTokenRepository struct {
+	repository.ActionTokenRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeActionTokenRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeLockoutEventRepository struct {
+	repository.LockoutEventRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeLockoutEventRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeSecurityEventRepository struct {
+	repository.SecurityEventRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeSecurityEventRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeUserIdentityRepository struct {
+	repository.UserIdentityRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeUserIdentityRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeDataExportRepository struct {
+	repository.DataExportRepository
+	owned ownedRecords
+}
+
+func (r *fakePurgeDataExportRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.owned.deleteAll(userID)
+}
+
+type fakePurgeOAuthClientService struct {
+	OAuthClientService
+}
+
+func (s *fakePurgeOAuthClientService) GetAllByOwnerId(ctx context.Context, ownerID primitive.ObjectID) ([]models.OAuthClient, error) {
+	return nil, nil
+}
+
+type purgeFixture struct {
+	service AccountPurgeService
+	users   *fakePurgeUserRepository
+	tasks   ownedRecords
+	events  ownedRecords
+	exports ownedRecords
+}
+
+func newPurgeFixture(pending []models.User, failing ...primitive.ObjectID) purgeFixture {
+	users := &fakePurgeUserRepository{
+		pending: pending,
+		failing: map[primitive.ObjectID]bool{},
+		deleted: map[primitive.ObjectID]bool{},
+	}
+	for _, id := range failing {
+		users.failing[id] = true
+	}
+
+	f := purgeFixture{
+		users:   users,
+		tasks:   ownedRecords{},
+		events:  ownedRecords{},
+		exports: ownedRecords{},
+	}
+
+	f.service = NewAccountPurgeService(
+		fakeTransactor{},
+		users,
+		&fakePurgeTaskRepository{owned: f.tasks},
+		NewSessionService(newFakeSessionRepository(), &fakeRevokedTokenRepository{revoked: map[string]bool{}}),
+		&fakePurgePersonalAccessTokenRepository{owned: ownedRecords{}},
+		&fakePurgeActionTokenRepository{owned: ownedRecords{}},
+		&fakePurgeLockoutEventRepository{owned: ownedRecords{}},
+		&fakePurgeSecurityEventRepository{owned: f.events},
+		&fakePurgeUserIdentityRepository{owned: ownedRecords{}},
+		&fakePurgeOAuthClientService{},
+		&fakePurgeDataExportRepository{owned: f.exports},
+	)
+
+	return f
+}
+
+func pendingUser(purgeAt time.Time) models.User {
+	deletedAt := purgeAt.Add(-14 * 24 * time.Hour)
+	return models.User{ID: primitive.NewObjectID(), DeletedAt: &deletedAt, PurgeAt: &purgeAt}
+}
+
+func TestAccountPurgeServicePurgeDue(t *testing.T) {
+	now := time.Now()
+	failing := pendingUser(now.Add(-3 * time.Hour))
+	first := pendingUser(now.Add(-2 * time.Hour))
+	second := pendingUser(now.Add(-time.Hour))
+	notDue := pendingUser(now.Add(time.Hour))
+
+	tests := []struct {
+		name        string
+		failing     []primitive.ObjectID
+		wantPurged  []models.User
+		wantKept    []models.User
+		wantErr     bool
+		wantRetried bool
+	}{
+		{
+			name:       "every due account",
+			wantPurged: []models.User{failing, first, second},
+			wantKept:   []models.User{notDue},
+		},
+		{
+			name:        "a failing account does not block the rest",
+			failing:     []primitive.ObjectID{failing.ID},
+			wantPurged:  []models.User{first, second},
+			wantKept:    []models.User{failing, notDue},
+			wantErr:     true,
+			wantRetried: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPurgeFixture([]models.User{failing, first, second, notDue}, tt.failing...)
+
+			purged, err := f.service.PurgeDue(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PurgeDue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if purged != len(tt.wantPurged) {
+				t.Fatalf("PurgeDue() purged %d accounts, want %d", purged, len(tt.wantPurged))
+			}
+
+			for _, user := range tt.wantPurged {
+				if !f.users.deleted[user.ID] {
+					t.Fatalf("user %s was not purged", user.ID.Hex())
+				}
+				if f.tasks[user.ID] != 1 || f.events[user.ID] != 1 || f.exports[user.ID] != 1 {
+					t.Fatalf("data of user %s not deleted: tasks %d, security events %d, exports %d",
+						user.ID.Hex(), f.tasks[user.ID], f.events[user.ID], f.exports[user.ID])
+				}
+			}
+
+			for _, user := range tt.wantKept {
+				if f.users.deleted[user.ID] || f.tasks[user.ID] != 0 {
+					t.Fatalf("user %s was purged", user.ID.Hex())
+				}
+			}
+
+			if !tt.wantRetried {
+				return
+			}
+
+			// The failing account stays at the head of the queue; the next run still
+			// only retries it.
+			purged, err = f.service.PurgeDue(context.Background())
+			if err == nil || purged != 0 {
+				t.Fatalf("second PurgeDue() = %d, %v, want 0 and the failing account's error", purged, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaskCounts struct {
+	Total   int64 `json:"total"`
+	Done    int64 `json:"done"`
+	Pending int64 `json:"pending"`
+}
+
+// Impersonation is an access token an admin uses to act as User.
+type Impersonation struct {
+	User      *models.User
+	Token     string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+type AdminService interface {
+	SearchUsers(ctx context.Context, query string, page, pageSize int) ([]models.User, int64, error)
+	GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	SetDisabled(ctx context.Context, actor *models.User, id primitive.ObjectID, disabled bool) (*models.User, error)
+	SetRole(ctx context.Context, actor *models.User, id primitive.ObjectID, role string) (*models.User, error)
+	ForceLogout(ctx context.Context, id primitive.ObjectID) (int64, error)
+	TaskCounts(ctx context.Context, id primitive.ObjectID) (*TaskCounts, error)
+	Impersonate(ctx context.Context, actor *models.User, id primitive.ObjectID) (*Impersonation, error)
+}
+
+type adminService struct {
+	userRepo         repository.UserRepository
+	taskRepo         repository.TaskRepository
+	sessionService   SessionService
+	impersonationTTL time.Duration
+}
+
+func NewAdminService(
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	sessionService SessionService,
+	impersonationTTL time.Duration,
+) AdminService {
+	return &adminService{
+		userRepo:         userRepo,
+		taskRepo:         taskRepo,
+		sessionService:   sessionService,
+		impersonationTTL: impersonationTTL,
+	}
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, query string, page, pageSize int) ([]models.User, int64, error) {
+	return s.userRepo.Search(ctx, query, page, pageSize)
+}
+
+func (s *adminService) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := s.userRepo.GetId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, apperr.NotFound("User not found")
+	}
+
+	return user, nil
+}
+
+// SetDisabled logs a disabled account out everywhere. Admins cannot disable themselves,
+// so the system always keeps at least the admin doing the change.
+func (s *adminService) SetDisabled(ctx context.Context, actor *models.User, id primitive.ObjectID, disabled bool) (*models.User, error) {
+	if disabled && actor.ID == id {
+		return nil, apperr.Validation("You cannot disable your own account")
+	}
+
+	user, err := s.userRepo.SetDisabled(ctx, id, disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if disabled {
+		if _, err := s.sessionService.RevokeAll(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *adminService) SetRole(ctx context.Context, actor *models.User, id primitive.ObjectID, role string) (*models.User, error) {
+	if !models.IsRole(role) {
+		return nil, apperr.Validation(fmt.Sprintf("Unknown role %q", role))
+	}
+
+	if actor.ID == id && role != actor.RoleName() {
+		return nil, apperr.Validation("You cannot change your own role")
+	}
+
+	return s.userRepo.SetRole(ctx, id, role)
+}
+
+func (s *adminService) ForceLogout(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	if _, err := s.GetUser(ctx, id); err != nil {
+		return 0, err
+	}
+
+	revoked, err := s.sessionService.RevokeAll(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+func (s *adminService) TaskCounts(ctx context.Context, id primitive.ObjectID) (*TaskCounts, error) {
+	if _, err := s.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	total, done, err := s.taskRepo.CountByUserId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskCounts{Total: total, Done: done, Pending: total - done}, nil
+}
+
+// Impersonate issues a short-lived token to act as another user. Admins cannot be
+// impersonated, so the token never carries more permissions than a plain user has.
+func (s *adminService) Impersonate(ctx context.Context, actor *models.User, id primitive.ObjectID) (*Impersonation, error) {
+	if actor.ID == id {
+		return nil, apperr.Validation("You cannot impersonate yourself")
+	}
+
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.Permissions()) > 0 {
+		return nil, apperr.Forbidden("Users with an admin role cannot be impersonated")
+	}
+
+	if user.Disabled || user.PendingDeletion() {
+		return nil, apperr.Conflict("Disabled accounts and accounts pending deletion cannot be impersonated")
+	}
+
+	token, claims, err := utils.GenerateImpersonationToken(user, actor, s.impersonationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Impersonation{
+		User:      user,
+		Token:     token,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeAdminUserRepository struct {
+	repository.UserRepository
+	users map[primitive.ObjectID]*models.User
+}
+
+func newFakeAdminUserRepository(users ...*models.User) *fakeAdminUserRepository {
+	r := &fakeAdminUserRepository{users: map[primitive.ObjectID]*models.User{}}
+	for _, user := range users {
+		copied := *user
+		r.users[user.ID] = &copied
+	}
+	return r
+}
+
+func (r *fakeAdminUserRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeAdminUserRepository) SetDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperr.NotFound("User not found")
+	}
+	user.Disabled = disabled
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeAdminUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperr.NotFound("User not found")
+	}
+	user.Role = role
+	copied := *user
+	return &copied, nil
+}
+
+func TestAdminServiceSetDisabled(t *testing.T) {
+	admin := &models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	user := &models.User{ID: primitive.NewObjectID()}
+
+	tests := []struct {
+		name         string
+		target       primitive.ObjectID
+		disabled     bool
+		wantKind     apperr.Kind
+		wantErr      bool
+		wantSessions int
+	}{
+		{name: "disable a user", target: user.ID, disabled: true, wantSessions: 0},
+		{name: "enable a user", target: user.ID, disabled: false, wantSessions: 1},
+		{name: "disable yourself", target: admin.ID, disabled: true, wantErr: true, wantKind: apperr.KindValidation, wantSessions: 1},
+		{name: "enable yourself", target: admin.ID, disabled: false, wantSessions: 1},
+		{name: "unknown user", target: primitive.NewObjectID(), disabled: true, wantErr: true, wantKind: apperr.KindNotFound, wantSessions: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeAdminUserRepository(admin, user)
+			sessions := newFakeSessionRepository(
+				models.Session{ID: primitive.NewObjectID(), UserID: tt.target, AccessTokenID: "target-jti"},
+			)
+			service := NewAdminService(users, nil, NewSessionService(sessions, &fakeRevokedTokenRepository{revoked: map[string]bool{}}), time.Minute)
+
+			updated, err := service.SetDisabled(context.Background(), admin, tt.target, tt.disabled)
+			if tt.wantErr {
+				if !apperr.Is(err, tt.wantKind) {
+					t.Fatalf("SetDisabled() error = %v, want kind %v", err, tt.wantKind)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("SetDisabled() error = %v", err)
+				}
+				if updated.Disabled != tt.disabled {
+					t.Fatalf("SetDisabled() disabled = %v, want %v", updated.Disabled, tt.disabled)
+				}
+			}
+
+			if len(sessions.sessions) != tt.wantSessions {
+				t.Fatalf("%d sessions left, want %d", len(sessions.sessions), tt.wantSessions)
+			}
+			if stored := users.users[admin.ID]; stored.Disabled {
+				t.Fatalf("the acting admin was disabled")
+			}
+		})
+	}
+}
+
+func TestAdminServiceSetRole(t *testing.T) {
+	admin := &models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	user := &models.User{ID: primitive.NewObjectID()}
+
+	tests := []struct {
+		name     string
+		target   primitive.ObjectID
+		role     string
+		wantKind apperr.Kind
+		wantErr  bool
+	}{
+		{name: "promote a user", target: user.ID, role: models.RoleAdmin},
+		{name: "demote yourself", target: admin.ID, role: models.RoleUser, wantErr: true, wantKind: apperr.KindValidation},
+		{name: "keep your own role", target: admin.ID, role: models.RoleAdmin},
+		{name: "unknown role", target: user.ID, role: "owner", wantErr: true, wantKind: apperr.KindValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeAdminUserRepository(admin, user)
+			service := NewAdminService(users, nil, nil, time.Minute)
+
+			updated, err := service.SetRole(context.Background(), admin, tt.target, tt.role)
+			if tt.wantErr {
+				if !apperr.Is(err, tt.wantKind) {
+					t.Fatalf("SetRole() error = %v, want kind %v", err, tt.wantKind)
+				}
+				if stored := users.users[tt.target]; stored.Role == tt.role {
+					t.Fatalf("role was changed to %q", tt.role)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetRole() error = %v", err)
+			}
+			if updated.Role != tt.role {
+				t.Fatalf("SetRole() role = %q, want %q", updated.Role, tt.role)
+			}
+		})
+	}
+}
+
+func TestAdminServiceImpersonate(t *testing.T) {
+	now := time.Now()
+	admin := &models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	otherAdmin := &models.User{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	user := &models.User{ID: primitive.NewObjectID()}
+	disabled := &models.User{ID: primitive.NewObjectID(), Disabled: true}
+	pending := &models.User{ID: primitive.NewObjectID(), DeletedAt: &now}
+
+	tests := []struct {
+		name     string
+		target   primitive.ObjectID
+		wantKind apperr.Kind
+		wantErr  bool
+	}{
+		{name: "plain user", target: user.ID},
+		{name: "yourself", target: admin.ID, wantErr: true, wantKind: apperr.KindValidation},
+		{name: "another admin", target: otherAdmin.ID, wantErr: true, wantKind: apperr.KindForbidden},
+		{name: "disabled account", target: disabled.ID, wantErr: true, wantKind: apperr.KindConflict},
+		{name: "account pending deletion", target: pending.ID, wantErr: true, wantKind: apperr.KindConflict},
+		{name: "unknown user", target: primitive.NewObjectID(), wantErr: true, wantKind: apperr.KindNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeAdminUserRepository(admin, otherAdmin, user, disabled, pending)
+			service := NewAdminService(users, nil, nil, 15*time.Minute)
+
+			impersonation, err := service.Impersonate(context.Background(), admin, tt.target)
+			if tt.wantErr {
+				if !apperr.Is(err, tt.wantKind) {
+					t.Fatalf("Impersonate() error = %v, want kind %v", err, tt.wantKind)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Impersonate() error = %v", err)
+			}
+
+			claims, err := utils.ParseToken(impersonation.Token, utils.AccessTokenType)
+			if err != nil {
+				t.Fatalf("impersonation token does not parse: %v", err)
+			}
+			if !claims.IsImpersonation() || claims.Act.Subject != admin.ID.Hex() || claims.UserID != tt.target.Hex() {
+				t.Fatalf("claims user %q act %+v, want the user with the admin as actor", claims.UserID, claims.Act)
+			}
+			if claims.ID != impersonation.TokenID {
+				t.Fatalf("token jti %q, want %q", claims.ID, impersonation.TokenID)
+			}
+			if until := time.Until(impersonation.ExpiresAt); until <= 0 || until > 15*time.Minute {
+				t.Fatalf("token expires in %v, want within the impersonation TTL", until)
+			}
+		})
+	}
+}
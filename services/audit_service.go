@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditService interface {
+	Record(ctx context.Context, entry *models.AuditLog) error
+	GetAll(ctx context.Context, actorID *primitive.ObjectID, targetID *primitive.ObjectID, page, pageSize int) ([]models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditLogRepository
+}
+
+func NewAuditService(repo repository.AuditLogRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(ctx context.Context, entry *models.AuditLog) error {
+	_, err := s.repo.Create(ctx, entry)
+	return err
+}
+
+func (s *auditService) GetAll(ctx context.Context, actorID *primitive.ObjectID, targetID *primitive.ObjectID, page, pageSize int) ([]models.AuditLog, int64, error) {
+	return s.repo.GetAll(ctx, actorID, targetID, page, pageSize)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+)
+
+// orphanReportLimit bounds how many owners one report lists.
+const orphanReportLimit = 100
+
+type OrphanReport struct {
+	CheckedAt time.Time              `json:"checked_at"`
+	Tasks     int64                  `json:"tasks"`
+	Owners    []models.OrphanedTasks `json:"owners"`
+}
+
+type ConsistencyService interface {
+	OrphanedTasks(ctx context.Context) (*OrphanReport, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type consistencyService struct {
+	taskRepo repository.TaskRepository
+}
+
+func NewConsistencyService(taskRepo repository.TaskRepository) ConsistencyService {
+	return &consistencyService{taskRepo: taskRepo}
+}
+
+// OrphanedTasks reports tasks whose user_id matches no user. It only reports them; the
+// owners are listed so they can be checked before anything is cleaned up by hand.
+func (s *consistencyService) OrphanedTasks(ctx context.Context) (*OrphanReport, error) {
+	owners, err := s.taskRepo.FindOrphaned(ctx, orphanReportLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OrphanReport{CheckedAt: time.Now(), Owners: owners}
+	for _, owner := range owners {
+		report.Tasks += owner.Count
+	}
+
+	return report, nil
+}
+
+// Run checks for orphaned tasks every interval until ctx is done and logs what it finds.
+func (s *consistencyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.OrphanedTasks(ctx)
+		if err != nil {
+			log.Printf("consistency check failed: %v", err)
+		} else if len(report.Owners) > 0 {
+			log.Printf("consistency check: %d orphaned tasks from %d missing users", report.Tasks, len(report.Owners))
+			for _, owner := range report.Owners {
+				log.Printf("consistency check: user %s is missing and still owns %d tasks", owner.UserID.Hex(), owner.Count)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
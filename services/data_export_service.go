@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/archive"
+	securityMappers "todolist-auth-fiber/utils/mappers/security"
+	sessionMappers "todolist-auth-fiber/utils/mappers/session"
+	userMappers "todolist-auth-fiber/utils/mappers/user"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// exportBuildTimeout bounds a single archive build.
+	exportBuildTimeout = 15 * time.Minute
+	// staleExportAfter is how long an export may stay running before it is considered
+	// abandoned by a worker that stopped.
+	staleExportAfter = 2 * exportBuildTimeout
+)
+
+type DataExportConfig struct {
+	Retention time.Duration
+	LinkTTL   time.Duration
+}
+
+type DataExportService interface {
+	Request(ctx context.Context, user *models.User) (*models.DataExport, error)
+	GetAll(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error)
+	Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*models.DataExport, error)
+	DownloadLink(user *models.User, export *models.DataExport) (string, time.Time, error)
+	Open(ctx context.Context, token string, id primitive.ObjectID) (*models.DataExport, io.ReadCloser, int64, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type dataExportService struct {
+	repo           repository.DataExportRepository
+	userRepo       repository.UserRepository
+	taskRepo       repository.TaskRepository
+	securityEvents repository.SecurityEventRepository
+	sessionService SessionService
+	tokenService   TokenService
+	baseURL        string
+	config         DataExportConfig
+	wake           chan struct{}
+}
+
+func NewDataExportService(
+	repo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	securityEvents repository.SecurityEventRepository,
+	sessionService SessionService,
+	tokenService TokenService,
+	baseURL string,
+	config DataExportConfig,
+) DataExportService {
+	return &dataExportService{
+		repo:           repo,
+		userRepo:       userRepo,
+		taskRepo:       taskRepo,
+		securityEvents: securityEvents,
+		sessionService: sessionService,
+		tokenService:   tokenService,
+		baseURL:        baseURL,
+		config:         config,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Request queues an export and nudges the worker. Only one export per user can be
+// pending or running at a time.
+func (s *dataExportService) Request(ctx context.Context, user *models.User) (*models.DataExport, error) {
+	export, err := s.repo.Create(ctx, &models.DataExport{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.config.Retention),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return export, nil
+}
+
+func (s *dataExportService) GetAll(ctx context.Context, userID primitive.ObjectID) ([]models.DataExport, error) {
+	return s.repo.GetAllByUserId(ctx, userID)
+}
+
+func (s *dataExportService) Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*models.DataExport, error) {
+	export, err := s.repo.GetById(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if export == nil {
+		return nil, apperr.NotFound("Data export not found")
+	}
+
+	return export, nil
+}
+
+// DownloadLink signs a short-lived link to a ready export. The token is bound to the
+// export and to the user's credentials version, so a password change kills it.
+func (s *dataExportService) DownloadLink(user *models.User, export *models.DataExport) (string, time.Time, error) {
+	ttl := s.config.LinkTTL
+	if remaining := time.Until(export.ExpiresAt); remaining < ttl {
+		ttl = remaining
+	}
+
+	claims := utils.NewClaims(user, primitive.NilObjectID, utils.DataExportTokenType, ttl)
+	claims.Subject = export.ID.Hex()
+
+	token, err := utils.SignClaims(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	link := s.baseURL + "/api/v1/users/exports/" + export.ID.Hex() + "/download?token=" + url.QueryEscape(token)
+
+	return link, claims.ExpiresAt.Time, nil
+}
+
+// Open checks a download token and opens the archive it points to. The caller closes
+// the returned reader.
+func (s *dataExportService) Open(ctx context.Context, token string, id primitive.ObjectID) (*models.DataExport, io.ReadCloser, int64, error) {
+	claims, user, err := s.tokenService.Validate(ctx, token, utils.DataExportTokenType)
+	if err != nil {
+		if apperr.Is(err, apperr.KindUnauthorized) {
+			return nil, nil, 0, apperr.Unauthorized("Download link invalid or expired")
+		}
+		return nil, nil, 0, err
+	}
+
+	if claims.Subject != id.Hex() {
+		return nil, nil, 0, apperr.Unauthorized("Download link invalid or expired")
+	}
+
+	export, err := s.Get(ctx, user.ID, id)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if export.Status != models.DataExportReady || export.FileID == nil {
+		return nil, nil, 0, apperr.Conflict("Data export is not ready")
+	}
+
+	if !export.ExpiresAt.After(time.Now()) {
+		return nil, nil, 0, apperr.Gone("Data export expired")
+	}
+
+	file, size, err := s.repo.OpenFile(ctx, *export.FileID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if file == nil {
+		return nil, nil, 0, apperr.Gone("Data export expired")
+	}
+
+	return export, file, size, nil
+}
+
+// Run builds queued exports and removes expired ones every interval, or as soon as an
+// export is requested, until ctx is done.
+func (s *dataExportService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.cleanup(ctx)
+		s.processPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *dataExportService) cleanup(ctx context.Context) {
+	now := time.Now()
+
+	if failed, err := s.repo.FailStale(ctx, now.Add(-staleExportAfter), now.Add(s.config.Retention)); err != nil {
+		log.Printf("data export cleanup failed: %v", err)
+	} else if failed > 0 {
+		log.Printf("data export cleanup: marked %d interrupted exports as failed", failed)
+	}
+
+	if deleted, err := s.repo.DeleteExpired(ctx, now); err != nil {
+		log.Printf("data export cleanup failed: %v", err)
+	} else if deleted > 0 {
+		log.Printf("data export cleanup: deleted %d expired exports", deleted)
+	}
+}
+
+func (s *dataExportService) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := s.repo.ClaimNext(ctx)
+		if err != nil {
+			log.Printf("data export: %v", err)
+			return
+		}
+
+		if export == nil {
+			return
+		}
+
+		s.process(ctx, export)
+	}
+}
+
+// process builds one export. Build errors are logged and the user only sees a generic
+// failure, since they may carry database details.
+func (s *dataExportService) process(ctx context.Context, export *models.DataExport) {
+	ctx, cancel := context.WithTimeout(ctx, exportBuildTimeout)
+	defer cancel()
+
+	fail := func(reason string) {
+		if err := s.repo.MarkFailed(ctx, export.ID, reason, time.Now().Add(s.config.Retention)); err != nil {
+			log.Printf("data export %s: %v", export.ID.Hex(), err)
+		}
+	}
+
+	user, err := s.userRepo.GetId(ctx, export.UserID)
+	if err != nil {
+		log.Printf("data export %s: %v", export.ID.Hex(), err)
+		fail("The export could not be built, please try again later")
+		return
+	}
+
+	if user == nil {
+		fail("The account no longer exists")
+		return
+	}
+
+	filename := "export-" + export.ID.Hex() + ".zip"
+	fileID, size, err := s.repo.Upload(ctx, filename, func(w io.Writer) error {
+		return s.write(ctx, w, user)
+	})
+	if err != nil {
+		log.Printf("data export %s: %v", export.ID.Hex(), err)
+		fail("The export could not be built, please try again later")
+		return
+	}
+
+	if err := s.repo.MarkReady(ctx, export.ID, fileID, size, time.Now().Add(s.config.Retention)); err != nil {
+		log.Printf("data export %s: %v", export.ID.Hex(), err)
+	}
+}
+
+// write produces the archive. Everything goes through DTOs or explicit columns so that
+// password hashes, TOTP secrets and token hashes never reach it.
+func (s *dataExportService) write(ctx context.Context, w io.Writer, user *models.User) error {
+	zw := archive.NewWriter(w)
+
+	if err := zw.JSON("profile.json", userMappers.UserToUserDTO(user)); err != nil {
+		return err
+	}
+
+	if err := s.writeTasks(ctx, zw, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.writeSessions(ctx, zw, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.writeSecurityEvents(ctx, zw, user.ID); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeTasks reads the tasks twice, once per file, since a zip entry must be complete
+// before the next one starts.
+func (s *dataExportService) writeTasks(ctx context.Context, zw *archive.Writer, userID primitive.ObjectID) error {
+	err := zw.JSONArray("tasks.json", func(emit func(v interface{}) error) error {
+		return s.taskRepo.ForEachByUserId(ctx, userID, func(task *models.Todo) error {
+			return emit(task)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	header := []string{"id", "title", "description", "done", "created_at", "updated_at"}
+	return zw.CSV("tasks.csv", header, func(emit func(record ...string) error) error {
+		return s.taskRepo.ForEachByUserId(ctx, userID, func(task *models.Todo) error {
+			return emit(
+				task.ID.Hex(),
+				task.Title,
+				task.Discription,
+				strconv.FormatBool(task.Done),
+				formatTime(task.CreatedAt),
+				formatTime(task.UpdatedAt),
+			)
+		})
+	})
+}
+
+func (s *dataExportService) writeSessions(ctx context.Context, zw *archive.Writer, userID primitive.ObjectID) error {
+	sessions, err := s.sessionService.GetAllByUserId(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = zw.JSONArray("sessions.json", func(emit func(v interface{}) error) error {
+		for i := range sessions {
+			if err := emit(sessionMappers.SessionToSessionDTO(&sessions[i], primitive.NilObjectID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	header := []string{"id", "user_agent", "ip", "client_id", "created_at", "last_used_at"}
+	return zw.CSV("sessions.csv", header, func(emit func(record ...string) error) error {
+		for _, session := range sessions {
+			err := emit(
+				session.ID.Hex(),
+				session.UserAgent,
+				session.IP,
+				session.ClientID,
+				formatTime(session.CreatedAt),
+				formatTime(session.LastUsedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeSecurityEvents exports the user's own security events through the same DTO the
+// security-events endpoint returns. Admin audit entries are not exported: they carry the
+// staff member's IP, user agent and id.
+func (s *dataExportService) writeSecurityEvents(ctx context.Context, zw *archive.Writer, userID primitive.ObjectID) error {
+	err := zw.JSONArray("security_events.json", func(emit func(v interface{}) error) error {
+		return s.securityEvents.ForEachByUserId(ctx, userID, func(event *models.SecurityEvent) error {
+			return emit(securityMappers.SecurityEventToSecurityEventDTO(event))
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	header := []string{"id", "type", "ip", "user_agent", "created_at"}
+	return zw.CSV("security_events.csv", header, func(emit func(record ...string) error) error {
+		return s.securityEvents.ForEachByUserId(ctx, userID, func(event *models.SecurityEvent) error {
+			return emit(
+				event.ID.Hex(),
+				event.Type,
+				event.IP,
+				event.UserAgent,
+				formatTime(event.CreatedAt),
+			)
+		})
+	})
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeDataExportRepository keeps exports in a queue and archives in memory.
+type fakeDataExportRepository struct {
+	repository.DataExportRepository
+	exports []*models.DataExport
+	files   map[primitive.ObjectID][]byte
+}
+
+func (r *fakeDataExportRepository) Create(ctx context.Context, export *models.DataExport) (*models.DataExport, error) {
+	export.ID = primitive.NewObjectID()
+	export.Status = models.DataExportPending
+	r.exports = append(r.exports, export)
+	copied := *export
+	return &copied, nil
+}
+
+func (r *fakeDataExportRepository) GetById(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*models.DataExport, error) {
+	for _, export := range r.exports {
+		if export.ID == id && export.UserID == userID {
+			copied := *export
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDataExportRepository) ClaimNext(ctx context.Context) (*models.DataExport, error) {
+	for _, export := range r.exports {
+		if export.Status == models.DataExportPending {
+			export.Status = models.DataExportRunning
+			copied := *export
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDataExportRepository) Upload(ctx context.Context, filename string, write func(w io.Writer) error) (primitive.ObjectID, int64, error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return primitive.NilObjectID, 0, err
+	}
+	id := primitive.NewObjectID()
+	r.files[id] = buf.Bytes()
+	return id, int64(buf.Len()), nil
+}
+
+func (r *fakeDataExportRepository) MarkReady(ctx context.Context, id primitive.ObjectID, fileID primitive.ObjectID, size int64, expiresAt time.Time) error {
+	for _, export := range r.exports {
+		if export.ID == id {
+			export.Status = models.DataExportReady
+			export.FileID = &fileID
+			export.Size = size
+			export.ExpiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataExportRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string, expiresAt time.Time) error {
+	for _, export := range r.exports {
+		if export.ID == id {
+			export.Status = models.DataExportFailed
+			export.Error = reason
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataExportRepository) OpenFile(ctx context.Context, fileID primitive.ObjectID) (io.ReadCloser, int64, error) {
+	content, ok := r.files[fileID]
+	if !ok {
+		return nil, 0, nil
+	}
+	return io.NopCloser(bytes.NewReader(content)), int64(len(content)), nil
+}
+
+type fakeExportUserRepository struct {
+	repository.UserRepository
+	users map[primitive.ObjectID]*models.User
+}
+
+func (r *fakeExportUserRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+type fakeExportTaskRepository struct {
+	repository.TaskRepository
+	tasks []models.Todo
+}
+
+func (r *fakeExportTaskRepository) ForEachByUserId(ctx context.Context, userId primitive.ObjectID, fn func(task *models.Todo) error) error {
+	for i := range r.tasks {
+		if r.tasks[i].UserID != userId {
+			continue
+		}
+		if err := fn(&r.tasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeExportSecurityEventRepository struct {
+	repository.SecurityEventRepository
+	events []models.SecurityEvent
+}
+
+func (r *fakeExportSecurityEventRepository) ForEachByUserId(ctx context.Context, userID primitive.ObjectID, fn func(event *models.SecurityEvent) error) error {
+	for i := range r.events {
+		if r.events[i].UserID == nil || *r.events[i].UserID != userID {
+			continue
+		}
+		if err := fn(&r.events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readArchive(t *testing.T, file io.Reader) map[string]string {
+	t.Helper()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("export is not a zip archive: %v", err)
+	}
+
+	entries := map[string]string{}
+	for _, entry := range reader.File {
+		f, err := entry.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[entry.Name] = string(data)
+	}
+	return entries
+}
+
+func TestDataExportServiceBuildAndDownload(t *testing.T) {
+	user := &models.User{
+		ID:         primitive.NewObjectID(),
+		Email:      "ada@example.com",
+		Username:   "ada",
+		Password:   "$argon2id$secret-password-hash",
+		TOTPSecret: "SECRETTOTPSEED",
+	}
+	other := primitive.NewObjectID()
+
+	exports := &fakeDataExportRepository{files: map[primitive.ObjectID][]byte{}}
+	users := &fakeExportUserRepository{users: map[primitive.ObjectID]*models.User{user.ID: user}}
+	tasks := &fakeExportTaskRepository{tasks: []models.Todo{
+		{ID: primitive.NewObjectID(), UserID: user.ID, Title: "=HYPERLINK(\"http://evil.test\")"},
+		{ID: primitive.NewObjectID(), UserID: other, Title: "someone else's task"},
+	}}
+	events := &fakeExportSecurityEventRepository{events: []models.SecurityEvent{
+		{ID: primitive.NewObjectID(), UserID: &user.ID, Type: models.SecurityLoginSucceeded, IP: "203.0.113.7"},
+		{ID: primitive.NewObjectID(), UserID: &other, Type: models.SecurityLoginSucceeded, IP: "198.51.100.1"},
+	}}
+	revoked := &fakeRevokedTokenRepository{revoked: map[string]bool{}}
+
+	service := NewDataExportService(
+		exports,
+		users,
+		tasks,
+		events,
+		NewSessionService(newFakeSessionRepository(), revoked),
+		NewTokenService(users, revoked),
+		"https://api.example.com",
+		DataExportConfig{Retention: 24 * time.Hour, LinkTTL: 15 * time.Minute},
+	)
+
+	requested, err := service.Request(context.Background(), user)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if requested.Status != models.DataExportPending {
+		t.Fatalf("Request() status = %q, want pending", requested.Status)
+	}
+
+	service.(*dataExportService).processPending(context.Background())
+
+	export, err := service.Get(context.Background(), user.ID, requested.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if export.Status != models.DataExportReady {
+		t.Fatalf("export status = %q (%s), want ready", export.Status, export.Error)
+	}
+	if _, err := service.Get(context.Background(), other, requested.ID); !apperr.Is(err, apperr.KindNotFound) {
+		t.Fatalf("Get() by another user: error = %v, want NotFound", err)
+	}
+
+	link, expiresAt, err := service.DownloadLink(user, export)
+	if err != nil {
+		t.Fatalf("DownloadLink() error = %v", err)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > 15*time.Minute {
+		t.Fatalf("link expires in %v, want within the link TTL", until)
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || !strings.HasPrefix(link, "https://api.example.com/api/v1/users/exports/"+export.ID.Hex()+"/download?") {
+		t.Fatalf("DownloadLink() = %q", link)
+	}
+	token := parsed.Query().Get("token")
+
+	_, file, size, err := service.Open(context.Background(), token, export.ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+	if size != export.Size {
+		t.Fatalf("Open() size = %d, want %d", size, export.Size)
+	}
+
+	entries := readArchive(t, file)
+	for _, name := range []string{"profile.json", "tasks.json", "tasks.csv", "sessions.json", "sessions.csv", "security_events.json", "security_events.csv"} {
+		if _, ok := entries[name]; !ok {
+			t.Fatalf("archive has no %s", name)
+		}
+	}
+
+	all := strings.Join([]string{entries["profile.json"], entries["tasks.json"], entries["security_events.json"], entries["security_events.csv"]}, "\n")
+	for _, secret := range []string{user.Password, user.TOTPSecret, "someone else's task", "198.51.100.1"} {
+		if strings.Contains(all, secret) {
+			t.Fatalf("archive contains %q", secret)
+		}
+	}
+	if !strings.Contains(entries["tasks.csv"], `'=HYPERLINK`) {
+		t.Fatalf("tasks.csv does not escape formulas:\n%s", entries["tasks.csv"])
+	}
+
+	t.Run("link of another export", func(t *testing.T) {
+		if _, _, _, err := service.Open(context.Background(), token, primitive.NewObjectID()); !apperr.Is(err, apperr.KindUnauthorized) {
+			t.Fatalf("Open() error = %v, want Unauthorized", err)
+		}
+	})
+
+	t.Run("access token used as a link", func(t *testing.T) {
+		access, err := utils.GenerateToken(user, primitive.NilObjectID, utils.AccessTokenType, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := service.Open(context.Background(), access, export.ID); !apperr.Is(err, apperr.KindUnauthorized) {
+			t.Fatalf("Open() error = %v, want Unauthorized", err)
+		}
+	})
+
+	t.Run("credentials changed after signing", func(t *testing.T) {
+		users.users[user.ID].CredentialsVersion++
+		defer func() { users.users[user.ID].CredentialsVersion-- }()
+
+		if _, _, _, err := service.Open(context.Background(), token, export.ID); !apperr.Is(err, apperr.KindUnauthorized) {
+			t.Fatalf("Open() error = %v, want Unauthorized", err)
+		}
+	})
+}
+
+func TestDataExportServiceFailsForMissingAccount(t *testing.T) {
+	exports := &fakeDataExportRepository{files: map[primitive.ObjectID][]byte{}}
+	users := &fakeExportUserRepository{users: map[primitive.ObjectID]*models.User{}}
+	service := NewDataExportService(exports, users, nil, nil, nil, nil, "", DataExportConfig{Retention: time.Hour, LinkTTL: time.Minute})
+
+	requested, err := service.Request(context.Background(), &models.User{ID: primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	service.(*dataExportService).processPending(context.Background())
+
+	export := exports.exports[0]
+	if export.ID != requested.ID || export.Status != models.DataExportFailed || export.Error != "The account no longer exists" {
+		t.Fatalf("export = %+v, want failed for the missing account", export)
+	}
+	if len(exports.files) != 0 {
+		t.Fatalf("an archive was built for a missing account")
+	}
+}
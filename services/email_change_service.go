@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const EmailChangeExpiration = time.Hour * 24
+
+type EmailChangeService interface {
+	Request(ctx context.Context, user *models.User, newEmail string, password string) error
+	Confirm(ctx context.Context, token string) (*models.User, error)
+	Cancel(ctx context.Context, token string) error
+}
+
+type emailChangeService struct {
+	userRepo     repository.UserRepository
+	actionTokens repository.ActionTokenRepository
+	mailer       mailer.Mailer
+	baseURL      string
+}
+
+func NewEmailChangeService(
+	userRepo repository.UserRepository,
+	actionTokens repository.ActionTokenRepository,
+	mailer mailer.Mailer,
+	baseURL string,
+) EmailChangeService {
+	return &emailChangeService{
+		userRepo:     userRepo,
+		actionTokens: actionTokens,
+		mailer:       mailer,
+		baseURL:      baseURL,
+	}
+}
+
+// Request reserves newEmail and mails a confirm link to it and a cancel link to the
+// current address. A new request replaces any pending one.
+func (s *emailChangeService) Request(ctx context.Context, user *models.User, newEmail string, password string) error {
+	if !crypto.Compare(password, user.Password) {
+		return apperr.Forbidden("Current password is incorrect")
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return apperr.Validation("New email must be different from the current one")
+	}
+
+	taken, err := s.userRepo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return apperr.Conflict("Email already in use")
+	}
+
+	if err := s.clearPending(ctx, user.ID); err != nil {
+		return err
+	}
+
+	confirmToken, err := s.createToken(ctx, user.ID, models.ActionEmailChange, newEmail)
+	if err != nil {
+		return err
+	}
+
+	cancelToken, err := s.createToken(ctx, user.ID, models.ActionEmailChangeCancel, newEmail)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Open the link below to use this address for your account:\n\n" +
+			s.baseURL + "/api/v1/users/email/confirm?token=" + url.QueryEscape(confirmToken) + "\n\n" +
+			"The link expires in 24 hours. If you did not ask for this change, ignore this email.",
+	})
+	if err != nil {
+		return fmt.Errorf("Error the send confirmation email: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your email is about to change",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone asked to change the email of your account to " + newEmail + ".\n" +
+			"If it was not you, open the link below to cancel the change and then change your password:\n\n" +
+			s.baseURL + "/api/v1/users/email/cancel?token=" + url.QueryEscape(cancelToken),
+	})
+	if err != nil {
+		return fmt.Errorf("Error the send cancellation email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailChangeService) Confirm(ctx context.Context, token string) (*models.User, error) {
+	change, err := s.actionTokens.Consume(ctx, models.ActionEmailChange, crypto.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if change == nil {
+		return nil, apperr.Validation("Confirmation link invalid or expired")
+	}
+
+	if err := s.clearPending(ctx, change.UserID); err != nil {
+		return nil, err
+	}
+
+	taken, err := s.userRepo.ExistsByEmail(ctx, change.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if taken {
+		return nil, apperr.Conflict("Email already in use")
+	}
+
+	return s.userRepo.ChangeEmail(ctx, change.UserID, change.Email)
+}
+
+func (s *emailChangeService) Cancel(ctx context.Context, token string) error {
+	cancel, err := s.actionTokens.Consume(ctx, models.ActionEmailChangeCancel, crypto.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if cancel == nil {
+		return apperr.Validation("Cancellation link invalid or expired")
+	}
+
+	return s.clearPending(ctx, cancel.UserID)
+}
+
+func (s *emailChangeService) createToken(ctx context.Context, userID primitive.ObjectID, purpose string, email string) (string, error) {
+	token, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.actionTokens.Create(ctx, &models.ActionToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		Email:     email,
+		TokenHash: crypto.HashToken(token),
+		ExpiresAt: time.Now().Add(EmailChangeExpiration),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *emailChangeService) clearPending(ctx context.Context, userID primitive.ObjectID) error {
+	for _, purpose := range []string{models.ActionEmailChange, models.ActionEmailChangeCancel} {
+		if _, err := s.actionTokens.DeleteAllByUserIdAndPurpose(ctx, userID, purpose); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
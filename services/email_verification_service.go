@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/mailer"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EmailVerificationService interface {
+	Send(ctx context.Context, user *models.User) error
+	SendAsync(user *models.User)
+	Verify(ctx context.Context, token string) (*models.User, error)
+}
+
+type emailVerificationService struct {
+	userRepo     repository.UserRepository
+	tokenService TokenService
+	mailer       mailer.Mailer
+	baseURL      string
+}
+
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	tokenService TokenService,
+	mailer mailer.Mailer,
+	baseURL string,
+) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+		mailer:       mailer,
+		baseURL:      baseURL,
+	}
+}
+
+func (s *emailVerificationService) Send(ctx context.Context, user *models.User) error {
+	if user.EmailVerified {
+		return apperr.Conflict("Email already verified")
+	}
+
+	token, err := utils.GenerateToken(user, primitive.NilObjectID, utils.EmailVerificationTokenType, utils.EmailVerificationTokenExpiration)
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/api/v1/users/verify-email?token=" + url.QueryEscape(token)
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Please confirm your email address by opening the link below:\n\n" +
+			link + "\n\n" +
+			"The link expires in 24 hours. If you did not create an account, ignore this email.",
+	})
+	if err != nil {
+		return fmt.Errorf("Error the send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// SendAsync sends the verification mail in the background, so signup answers without
+// waiting for SMTP, as it does for a taken email in silent signup mode.
+func (s *emailVerificationService) SendAsync(user *models.User) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.Send(ctx, user); err != nil {
+			log.Printf("failed to send verification email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+}
+
+func (s *emailVerificationService) Verify(ctx context.Context, token string) (*models.User, error) {
+	claims, user, err := s.tokenService.Validate(ctx, token, utils.EmailVerificationTokenType)
+	if err != nil {
+		if apperr.Is(err, apperr.KindUnauthorized) {
+			return nil, apperr.Validation("Verification link invalid or expired")
+		}
+		return nil, err
+	}
+
+	if claims.Email != user.Email {
+		return nil, apperr.Validation("Verification link invalid or expired")
+	}
+
+	if err := s.tokenService.Revoke(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, user.ID, claims.Email)
+}
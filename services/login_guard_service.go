@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LoginGuardConfig struct {
+	AccountThreshold int
+	IPThreshold      int
+	BaseLockout      time.Duration
+	MaxLockout       time.Duration
+	Window           time.Duration
+}
+
+type LoginGuardService interface {
+	Check(ctx context.Context, email string, ip string) (*time.Time, error)
+	RegisterFailure(ctx context.Context, user *models.User, email string, ip string) error
+	RegisterSuccess(ctx context.Context, email string) error
+	Notices(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+}
+
+type loginGuardService struct {
+	attempts repository.LoginAttemptRepository
+	events   repository.LockoutEventRepository
+	config   LoginGuardConfig
+}
+
+func NewLoginGuardService(
+	attempts repository.LoginAttemptRepository,
+	events repository.LockoutEventRepository,
+	config LoginGuardConfig,
+) LoginGuardService {
+	return &loginGuardService{
+		attempts: attempts,
+		events:   events,
+		config:   config,
+	}
+}
+
+// Check returns the time until which logins for the account or the ip are refused, if any.
+func (s *loginGuardService) Check(ctx context.Context, email string, ip string) (*time.Time, error) {
+	now := time.Now()
+	var lockedUntil *time.Time
+
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		attempt, err := s.attempts.GetById(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt == nil || attempt.LockedUntil == nil || !attempt.LockedUntil.After(now) {
+			continue
+		}
+
+		if lockedUntil == nil || attempt.LockedUntil.After(*lockedUntil) {
+			lockedUntil = attempt.LockedUntil
+		}
+	}
+
+	if lockedUntil != nil {
+		return lockedUntil, apperr.TooManyRequests("Too many failed login attempts, try again later")
+	}
+
+	return nil, nil
+}
+
+func (s *loginGuardService) RegisterFailure(ctx context.Context, user *models.User, email string, ip string) error {
+	scopes := []struct {
+		scope     string
+		key       string
+		threshold int
+	}{
+		{models.LockoutScopeAccount, accountKey(email), s.config.AccountThreshold},
+		{models.LockoutScopeIP, ipKey(ip), s.config.IPThreshold},
+	}
+
+	for _, scope := range scopes {
+		attempt, err := s.attempts.IncrementFailures(ctx, scope.key, s.config.Window)
+		if err != nil {
+			return err
+		}
+
+		if scope.threshold <= 0 || attempt.Failures < scope.threshold {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(s.lockoutFor(attempt.Failures - scope.threshold))
+		if err := s.attempts.Lock(ctx, scope.key, lockedUntil); err != nil {
+			return err
+		}
+
+		event := models.LockoutEvent{
+			Scope:       scope.scope,
+			Email:       normalizeEmail(email),
+			IP:          ip,
+			Failures:    attempt.Failures,
+			LockedUntil: lockedUntil,
+		}
+		if user != nil {
+			event.UserID = &user.ID
+		}
+
+		if _, err := s.events.Create(ctx, &event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterSuccess resets the account counter only. The ip counter expires on its own,
+// otherwise logging into an own account between guesses would reset the ip throttle.
+func (s *loginGuardService) RegisterSuccess(ctx context.Context, email string) error {
+	return s.attempts.Delete(ctx, accountKey(email))
+}
+
+func (s *loginGuardService) Notices(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	events, err := s.events.GetUnseenByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	notices := []string{}
+	for _, event := range events {
+		notices = append(notices, fmt.Sprintf(
+			"Logins to your account were locked until %s after %d failed attempts from %s",
+			event.LockedUntil.UTC().Format(time.RFC3339), event.Failures, event.IP,
+		))
+	}
+
+	if _, err := s.events.MarkSeenByUserId(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return notices, nil
+}
+
+// lockoutFor doubles the base lockout for every failure over the threshold, up to the max.
+func (s *loginGuardService) lockoutFor(extraFailures int) time.Duration {
+	if extraFailures > 20 {
+		extraFailures = 20
+	}
+
+	lockout := s.config.BaseLockout << extraFailures
+	if s.config.MaxLockout > 0 && lockout > s.config.MaxLockout {
+		return s.config.MaxLockout
+	}
+
+	return lockout
+}
+
+func accountKey(email string) string {
+	return models.LockoutScopeAccount + ":" + normalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return models.LockoutScopeIP + ":" + ip
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+)
+
+type fakeLoginAttemptRepository struct {
+	repository.LoginAttemptRepository
+	attempts map[string]*models.LoginAttempt
+}
+
+func (r *fakeLoginAttemptRepository) GetById(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *fakeLoginAttemptRepository) IncrementFailures(ctx context.Context, key string, window time.Duration) (*models.LoginAttempt, error) {
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt = &models.LoginAttempt{ID: key}
+		r.attempts[key] = attempt
+	}
+	attempt.Failures++
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *fakeLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	attempt := r.attempts[key]
+	if attempt.LockedUntil == nil || until.After(*attempt.LockedUntil) {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) Delete(ctx context.Context, key string) error {
+	delete(r.attempts, key)
+	return nil
+}
+
+type fakeLockoutEventRepository struct {
+	repository.LockoutEventRepository
+	created []models.LockoutEvent
+}
+
+func (r *fakeLockoutEventRepository) Create(ctx context.Context, event *models.LockoutEvent) (*models.LockoutEvent, error) {
+	r.created = append(r.created, *event)
+	return event, nil
+}
+
+func TestLoginGuardServiceBackoff(t *testing.T) {
+	config := LoginGuardConfig{
+		AccountThreshold: 3,
+		IPThreshold:      100,
+		BaseLockout:      time.Minute,
+		MaxLockout:       10 * time.Minute,
+		Window:           time.Hour,
+	}
+
+	// Failure n locks the account for the lockout listed at index n-1.
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+
+	attempts := &fakeLoginAttemptRepository{attempts: map[string]*models.LoginAttempt{}}
+	events := &fakeLockoutEventRepository{}
+	service := NewLoginGuardService(attempts, events, config)
+	user := &models.User{Email: "ada@example.com"}
+
+	for i, lockout := range want {
+		before := time.Now()
+		if err := service.RegisterFailure(context.Background(), user, " Ada@Example.com ", "203.0.113.7"); err != nil {
+			t.Fatalf("failure %d: RegisterFailure() error = %v", i+1, err)
+		}
+
+		lockedUntil, err := service.Check(context.Background(), "ada@example.com", "198.51.100.1")
+		if lockout == 0 {
+			if err != nil || lockedUntil != nil {
+				t.Fatalf("failure %d: Check() = %v, %v, want no lock", i+1, lockedUntil, err)
+			}
+			continue
+		}
+
+		if !apperr.Is(err, apperr.KindTooManyRequests) {
+			t.Fatalf("failure %d: Check() error = %v, want TooManyRequests", i+1, err)
+		}
+		if got := lockedUntil.Sub(before); got < lockout || got > lockout+time.Second {
+			t.Fatalf("failure %d: locked for %v, want %v", i+1, got, lockout)
+		}
+	}
+
+	if len(events.created) != len(want)-2 {
+		t.Fatalf("recorded %d lockout events, want %d", len(events.created), len(want)-2)
+	}
+	if event := events.created[0]; event.Scope != models.LockoutScopeAccount || event.Email != "ada@example.com" || event.Failures != 3 {
+		t.Fatalf("first lockout event = %+v", event)
+	}
+
+	if err := service.RegisterSuccess(context.Background(), "ada@example.com"); err != nil {
+		t.Fatalf("RegisterSuccess() error = %v", err)
+	}
+	if lockedUntil, err := service.Check(context.Background(), "ada@example.com", "198.51.100.1"); err != nil || lockedUntil != nil {
+		t.Fatalf("Check() after a successful login = %v, %v, want no lock", lockedUntil, err)
+	}
+}
+
+func TestLoginGuardServiceIPLockout(t *testing.T) {
+	config := LoginGuardConfig{
+		AccountThreshold: 100,
+		IPThreshold:      2,
+		BaseLockout:      time.Minute,
+		MaxLockout:       time.Hour,
+		Window:           time.Hour,
+	}
+
+	attempts := &fakeLoginAttemptRepository{attempts: map[string]*models.LoginAttempt{}}
+	service := NewLoginGuardService(attempts, &fakeLockoutEventRepository{}, config)
+
+	// Guessing across accounts from one address locks the address.
+	for _, email := range []string{"ada@example.com", "grace@example.com"} {
+		if err := service.RegisterFailure(context.Background(), nil, email, "203.0.113.7"); err != nil {
+			t.Fatalf("RegisterFailure() error = %v", err)
+		}
+	}
+
+	if _, err := service.Check(context.Background(), "linus@example.com", "203.0.113.7"); !apperr.Is(err, apperr.KindTooManyRequests) {
+		t.Fatalf("Check() from the guessing address: error = %v, want TooManyRequests", err)
+	}
+	if _, err := service.Check(context.Background(), "linus@example.com", "198.51.100.1"); err != nil {
+		t.Fatalf("Check() from another address: error = %v", err)
+	}
+
+	// Logging into an own account from that address does not lift the lock.
+	if err := service.RegisterSuccess(context.Background(), "mallory@example.com"); err != nil {
+		t.Fatalf("RegisterSuccess() error = %v", err)
+	}
+	if _, err := service.Check(context.Background(), "linus@example.com", "203.0.113.7"); !apperr.Is(err, apperr.KindTooManyRequests) {
+		t.Fatalf("Check() after an own login: error = %v, want the address still locked", err)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+)
+
+const MagicLinkExpiration = time.Minute * 15
+
+type MagicLinkConfig struct {
+	MaxRequests int
+	Window      time.Duration
+}
+
+type MagicLinkService interface {
+	Request(ctx context.Context, email string) error
+	Redeem(ctx context.Context, token string) (*models.User, error)
+}
+
+type magicLinkService struct {
+	userRepo     repository.UserRepository
+	actionTokens repository.ActionTokenRepository
+	counters     repository.LoginAttemptRepository
+	mailer       mailer.Mailer
+	frontendURL  string
+	config       MagicLinkConfig
+}
+
+func NewMagicLinkService(
+	userRepo repository.UserRepository,
+	actionTokens repository.ActionTokenRepository,
+	counters repository.LoginAttemptRepository,
+	mailer mailer.Mailer,
+	frontendURL string,
+	config MagicLinkConfig,
+) MagicLinkService {
+	return &magicLinkService{
+		userRepo:     userRepo,
+		actionTokens: actionTokens,
+		counters:     counters,
+		mailer:       mailer,
+		frontendURL:  frontendURL,
+		config:       config,
+	}
+}
+
+// Request answers the same way for unknown emails and for accounts over their request
+// limit, so neither can be told apart from a sent link.
+func (s *magicLinkService) Request(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	// The login attempt counters double as a per-account request counter for links.
+	requests, err := s.counters.IncrementFailures(ctx, "magic_link:"+user.ID.Hex(), s.config.Window)
+	if err != nil {
+		return err
+	}
+
+	if requests.Failures > s.config.MaxRequests {
+		log.Printf("magic link request limit reached for user %s", user.ID.Hex())
+		return nil
+	}
+
+	if _, err := s.actionTokens.DeleteAllByUserIdAndPurpose(ctx, user.ID, models.ActionMagicLink); err != nil {
+		return err
+	}
+
+	token, err := crypto.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.actionTokens.Create(ctx, &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.ActionMagicLink,
+		TokenHash: crypto.HashToken(token),
+		ExpiresAt: time.Now().Add(MagicLinkExpiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Open the link below to sign in:\n\n" +
+			s.frontendURL + "/magic-link?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link works once and expires in 15 minutes. If you did not ask for it, ignore this email.",
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("failed to send magic link email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+
+	return nil
+}
+
+// Redeem consumes the link and returns its user. Receiving the link proves the user owns
+// the address, so the email is marked verified as well.
+func (s *magicLinkService) Redeem(ctx context.Context, token string) (*models.User, error) {
+	link, err := s.actionTokens.Consume(ctx, models.ActionMagicLink, crypto.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if link == nil {
+		return nil, apperr.Validation("Sign-in link invalid or expired")
+	}
+
+	user, err := s.userRepo.GetId(ctx, link.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, apperr.Validation("Sign-in link invalid or expired")
+	}
+
+	if !user.EmailVerified {
+		return s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/mailer"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeMagicLinkUserRepository struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r *fakeMagicLinkUserRepository) GetEmail(ctx context.Context, email string) (*models.User, error) {
+	if email != r.user.Email {
+		return nil, nil
+	}
+	copied := *r.user
+	return &copied, nil
+}
+
+func (r *fakeMagicLinkUserRepository) GetId(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	if id != r.user.ID {
+		return nil, nil
+	}
+	copied := *r.user
+	return &copied, nil
+}
+
+func (r *fakeMagicLinkUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) (*models.User, error) {
+	r.user.EmailVerified = true
+	copied := *r.user
+	return &copied, nil
+}
+
+// fakeActionTokenRepository keeps the live tokens by hash.
+type fakeActionTokenRepository struct {
+	repository.ActionTokenRepository
+	tokens  map[string]models.ActionToken
+	created int
+}
+
+func (r *fakeActionTokenRepository) Create(ctx context.Context, token *models.ActionToken) (*models.ActionToken, error) {
+	r.tokens[token.TokenHash] = *token
+	r.created++
+	return token, nil
+}
+
+func (r *fakeActionTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string) (*models.ActionToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose {
+		return nil, nil
+	}
+	delete(r.tokens, tokenHash)
+	return &token, nil
+}
+
+func (r *fakeActionTokenRepository) DeleteAllByUserIdAndPurpose(ctx context.Context, userID primitive.ObjectID, purpose string) (int64, error) {
+	var deleted int64
+	for hash, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(r.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type fakeMailer struct {
+	sent chan mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func (m *fakeMailer) next(t *testing.T) mailer.Message {
+	t.Helper()
+
+	select {
+	case msg := <-m.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("no email was sent")
+		return mailer.Message{}
+	}
+}
+
+func magicLinkToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+
+	_, link, found := strings.Cut(msg.Body, "/magic-link?token=")
+	if !found {
+		t.Fatalf("email has no sign-in link: %q", msg.Body)
+	}
+	link, _, _ = strings.Cut(link, "\n")
+
+	token, err := url.QueryUnescape(link)
+	if err != nil {
+		t.Fatalf("sign-in link does not decode: %v", err)
+	}
+	return token
+}
+
+func TestMagicLinkServiceRequestLimit(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Username: "ada"}
+	tokens := &fakeActionTokenRepository{tokens: map[string]models.ActionToken{}}
+	mail := &fakeMailer{sent: make(chan mailer.Message, 10)}
+
+	service := NewMagicLinkService(
+		&fakeMagicLinkUserRepository{user: user},
+		tokens,
+		&fakeLoginAttemptRepository{attempts: map[string]*models.LoginAttempt{}},
+		mail,
+		"https://app.example.com",
+		MagicLinkConfig{MaxRequests: 2, Window: time.Hour},
+	)
+
+	for i := 1; i <= 4; i++ {
+		if err := service.Request(context.Background(), "ada@example.com"); err != nil {
+			t.Fatalf("request %d: Request() error = %v", i, err)
+		}
+	}
+
+	if tokens.created != 2 {
+		t.Fatalf("created %d links, want 2 within the limit", tokens.created)
+	}
+	if len(tokens.tokens) != 1 {
+		t.Fatalf("%d links are live, want only the latest", len(tokens.tokens))
+	}
+
+	// The mails go out in the background, in either order.
+	sent := []string{magicLinkToken(t, mail.next(t)), magicLinkToken(t, mail.next(t))}
+
+	// Unknown emails answer like a sent link and send nothing.
+	if err := service.Request(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("Request() for an unknown email: error = %v", err)
+	}
+	if tokens.created != 2 {
+		t.Fatalf("a link was created for an unknown email")
+	}
+
+	// A new link replaces the previous one, so only one of them signs in.
+	redeemed := 0
+	for _, token := range sent {
+		user, err := service.Redeem(context.Background(), token)
+		if apperr.Is(err, apperr.KindValidation) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Redeem() error = %v", err)
+		}
+		if !user.EmailVerified {
+			t.Fatalf("Redeem() did not verify the email")
+		}
+		redeemed++
+
+		if _, err := service.Redeem(context.Background(), token); !apperr.Is(err, apperr.KindValidation) {
+			t.Fatalf("second Redeem() error = %v, want Validation", err)
+		}
+	}
+	if redeemed != 1 {
+		t.Fatalf("%d links signed in, want 1", redeemed)
+	}
+}
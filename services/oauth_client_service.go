@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/oauth"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OAuthClientService interface {
+	Register(ctx context.Context, owner *models.User, name string, redirectURIs []string, scopes []string, confidential bool) (string, *models.OAuthClient, error)
+	GetAllByOwnerId(ctx context.Context, ownerID primitive.ObjectID) ([]models.OAuthClient, error)
+	Delete(ctx context.Context, ownerID primitive.ObjectID, clientID string) error
+	Get(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	Authenticate(ctx context.Context, clientID string, secret string) (*models.OAuthClient, error)
+}
+
+type oauthClientService struct {
+	repo           repository.OAuthClientRepository
+	sessionService SessionService
+}
+
+func NewOAuthClientService(repo repository.OAuthClientRepository, sessionService SessionService) OAuthClientService {
+	return &oauthClientService{
+		repo:           repo,
+		sessionService: sessionService,
+	}
+}
+
+// Register returns the client secret next to the client for confidential clients. Only its
+// hash is stored, so it cannot be shown again.
+func (s *oauthClientService) Register(ctx context.Context, owner *models.User, name string, redirectURIs []string, scopes []string, confidential bool) (string, *models.OAuthClient, error) {
+	for _, uri := range redirectURIs {
+		if !oauth.ValidRedirectURI(uri) {
+			return "", nil, apperr.Validation(fmt.Sprintf("Redirect URI %q must use https, http on a loopback address or a private-use scheme, and have no fragment", uri))
+		}
+	}
+
+	clientID, err := crypto.RandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       uniqueScopes(scopes),
+		OwnerID:      owner.ID,
+	}
+
+	var secret string
+	if confidential {
+		secret, err = crypto.RandomToken(32)
+		if err != nil {
+			return "", nil, err
+		}
+		client.SecretHash = crypto.HashToken(secret)
+	}
+
+	saved, err := s.repo.Create(ctx, client)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secret, saved, nil
+}
+
+func (s *oauthClientService) GetAllByOwnerId(ctx context.Context, ownerID primitive.ObjectID) ([]models.OAuthClient, error) {
+	return s.repo.GetAllByOwnerId(ctx, ownerID)
+}
+
+// Delete removes the client and every session it holds, so its tokens stop working at once.
+func (s *oauthClientService) Delete(ctx context.Context, ownerID primitive.ObjectID, clientID string) error {
+	if err := s.repo.Delete(ctx, ownerID, clientID); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionService.RevokeAllByClientId(ctx, clientID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *oauthClientService) Get(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	client, err := s.repo.GetByClientId(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		return nil, oauth.NewError(400, oauth.ErrInvalidRequest, "Unknown client_id")
+	}
+
+	return client, nil
+}
+
+func (s *oauthClientService) Authenticate(ctx context.Context, clientID string, secret string) (*models.OAuthClient, error) {
+	invalid := oauth.NewError(401, oauth.ErrInvalidClient, "Client authentication failed")
+
+	if clientID == "" {
+		return nil, invalid
+	}
+
+	client, err := s.repo.GetByClientId(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		return nil, invalid
+	}
+
+	if !client.Confidential() {
+		if secret != "" {
+			return nil, invalid
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(crypto.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, invalid
+	}
+
+	return client, nil
+}
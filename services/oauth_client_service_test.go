@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/apperr"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOAuthClientServiceRegisterRejectsUnsafeRedirectURIs(t *testing.T) {
+	owner := &models.User{ID: primitive.NewObjectID()}
+	// The repository is never reached: the redirect URIs are checked first.
+	service := NewOAuthClientService(nil, nil)
+
+	for _, uri := range []string{
+		"javascript:alert(1)",
+		"data:text/html,<script>alert(1)</script>",
+		"file:///etc/passwd",
+		"http://app.example.com/callback",
+	} {
+		t.Run(uri, func(t *testing.T) {
+			redirectURIs := []string{"https://app.example.com/callback", uri}
+
+			_, client, err := service.Register(context.Background(), owner, "app", redirectURIs, []string{models.ScopeTasksRead}, false)
+			if !apperr.Is(err, apperr.KindValidation) {
+				t.Fatalf("Register() error = %v, want Validation", err)
+			}
+			if client != nil {
+				t.Fatalf("Register() returned a client")
+			}
+		})
+	}
+}
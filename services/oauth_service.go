@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"time"
+	oauthdto "todolist-auth-fiber/dtos/oauthDto"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/oauth"
+)
+
+const OAuthCodeExpiration = time.Minute * 5
+
+type OAuthService interface {
+	Authorize(ctx context.Context, req oauthdto.AuthorizeDTO) (*oauthdto.AuthorizationPromptDTO, error)
+	Approve(ctx context.Context, user *models.User, req oauthdto.AuthorizeDTO) (string, error)
+	Deny(ctx context.Context, req oauthdto.AuthorizeDTO) (string, error)
+	Exchange(ctx context.Context, client *models.OAuthClient, req oauthdto.TokenRequestDTO, userAgent string, ip string) (*oauth.TokenResponse, error)
+	Introspect(ctx context.Context, client *models.OAuthClient, token string) (*oauth.Introspection, error)
+	Revoke(ctx context.Context, client *models.OAuthClient, token string) error
+}
+
+type oauthService struct {
+	clients        OAuthClientService
+	codes          repository.OAuthCodeRepository
+	userRepo       repository.UserRepository
+	sessionService SessionService
+	tokenService   TokenService
+}
+
+func NewOAuthService(
+	clients OAuthClientService,
+	codes repository.OAuthCodeRepository,
+	userRepo repository.UserRepository,
+	sessionService SessionService,
+	tokenService TokenService,
+) OAuthService {
+	return &oauthService{
+		clients:        clients,
+		codes:          codes,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		tokenService:   tokenService,
+	}
+}
+
+// Authorize validates an authorization request and describes it for the consent screen.
+func (s *oauthService) Authorize(ctx context.Context, req oauthdto.AuthorizeDTO) (*oauthdto.AuthorizationPromptDTO, error) {
+	client, redirectURI, scopes, err := s.validate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauthdto.AuthorizationPromptDTO{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: redirectURI,
+		Scopes:      scopes,
+		State:       req.State,
+	}, nil
+}
+
+// Approve issues an authorization code and returns where to send the user with it.
+func (s *oauthService) Approve(ctx context.Context, user *models.User, req oauthdto.AuthorizeDTO) (string, error) {
+	client, redirectURI, scopes, err := s.validate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	authorizationCode, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.codes.Create(ctx, &models.OAuthAuthorizationCode{
+		CodeHash:            crypto.HashToken(authorizationCode),
+		ClientID:            client.ClientID,
+		UserID:              user.ID,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(OAuthCodeExpiration),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("code", authorizationCode)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	return oauth.AppendQuery(redirectURI, params), nil
+}
+
+func (s *oauthService) Deny(ctx context.Context, req oauthdto.AuthorizeDTO) (string, error) {
+	_, redirectURI, _, err := s.validate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	denied := oauth.NewError(400, oauth.ErrAccessDenied, "The user denied the request").Redirect(redirectURI, req.State)
+	return denied.RedirectTo(), nil
+}
+
+// validate checks an authorization request. Problems with the client or its redirect URI
+// are returned as plain errors; anything else is an error sent back through the redirect URI.
+func (s *oauthService) validate(ctx context.Context, req oauthdto.AuthorizeDTO) (*models.OAuthClient, string, []string, error) {
+	client, err := s.clients.Get(ctx, req.ClientID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+
+	if !client.AllowsRedirectURI(redirectURI) {
+		return nil, "", nil, oauth.NewError(400, oauth.ErrInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, "", nil, oauth.NewError(400, oauth.ErrUnsupportedResponseType, "Only the code response type is supported").Redirect(redirectURI, req.State)
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != oauth.PKCEMethodS256 {
+		return nil, "", nil, oauth.NewError(400, oauth.ErrInvalidRequest, "PKCE with code_challenge_method=S256 is required").Redirect(redirectURI, req.State)
+	}
+
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return nil, "", nil, oauth.NewError(400, oauth.ErrInvalidScope, "Scope "+scope+" is not allowed for this client").Redirect(redirectURI, req.State)
+		}
+	}
+
+	return client, redirectURI, uniqueScopes(scopes), nil
+}
+
+func (s *oauthService) Exchange(ctx context.Context, client *models.OAuthClient, req oauthdto.TokenRequestDTO, userAgent string, ip string) (*oauth.TokenResponse, error) {
+	switch req.GrantType {
+	case "authorization_code":
+		return s.exchangeCode(ctx, client, req, userAgent, ip)
+	case "refresh_token":
+		return s.exchangeRefreshToken(ctx, client, req)
+	default:
+		return nil, oauth.NewError(400, oauth.ErrUnsupportedGrantType, "Supported grant types are authorization_code and refresh_token")
+	}
+}
+
+func (s *oauthService) exchangeCode(ctx context.Context, client *models.OAuthClient, req oauthdto.TokenRequestDTO, userAgent string, ip string) (*oauth.TokenResponse, error) {
+	invalid := oauth.NewError(400, oauth.ErrInvalidGrant, "Authorization code invalid, expired or already used")
+
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauth.NewError(400, oauth.ErrInvalidRequest, "code and code_verifier are required")
+	}
+
+	authorizationCode, err := s.codes.Consume(ctx, crypto.HashToken(req.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	if authorizationCode == nil || authorizationCode.ClientID != client.ClientID {
+		return nil, invalid
+	}
+
+	// RFC 6749 section 4.1.3: redirect_uri must be sent again, identical, when the
+	// authorization request had one.
+	if req.RedirectURI != authorizationCode.RedirectURI {
+		return nil, invalid
+	}
+
+	if !oauth.VerifyPKCE(req.CodeVerifier, authorizationCode.CodeChallenge, authorizationCode.CodeChallengeMethod) {
+		return nil, invalid
+	}
+
+	user, err := s.userRepo.GetId(ctx, authorizationCode.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || user.Disabled || user.PendingDeletion() {
+		return nil, invalid
+	}
+
+	tokens, err := s.sessionService.StartForClient(ctx, user, client.ClientID, authorizationCode.Scopes, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth.TokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(utils.OAuthAccessTokenExpiration.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        oauth.FormatScope(authorizationCode.Scopes),
+	}, nil
+}
+
+func (s *oauthService) exchangeRefreshToken(ctx context.Context, client *models.OAuthClient, req oauthdto.TokenRequestDTO) (*oauth.TokenResponse, error) {
+	invalid := oauth.NewError(400, oauth.ErrInvalidGrant, "Refresh token invalid, expired or revoked")
+
+	claims, user, err := s.tokenService.Validate(ctx, req.RefreshToken, utils.RefreshTokenType)
+	if err != nil {
+		if apperr.IsInternal(err) {
+			return nil, err
+		}
+		return nil, invalid
+	}
+
+	if claims.ClientID != client.ClientID {
+		return nil, invalid
+	}
+
+	sessionID, err := claims.SessionObjectID()
+	if err != nil {
+		return nil, invalid
+	}
+
+	tokens, err := s.sessionService.Refresh(ctx, user, sessionID, client.ClientID, req.RefreshToken)
+	if err != nil {
+		if apperr.IsInternal(err) {
+			return nil, err
+		}
+		return nil, invalid
+	}
+
+	return &oauth.TokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(utils.OAuthAccessTokenExpiration.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        claims.Scope,
+	}, nil
+}
+
+// Introspect only describes tokens issued to the calling client; anything else is inactive.
+func (s *oauthService) Introspect(ctx context.Context, client *models.OAuthClient, token string) (*oauth.Introspection, error) {
+	inactive := &oauth.Introspection{Active: false}
+
+	claims, user, err := s.validateAny(ctx, token)
+	if err != nil {
+		if apperr.IsInternal(err) {
+			return nil, err
+		}
+		return inactive, nil
+	}
+
+	if claims.ClientID != client.ClientID {
+		return inactive, nil
+	}
+
+	if claims.Type == utils.RefreshTokenType {
+		sessionID, err := claims.SessionObjectID()
+		if err != nil {
+			return inactive, nil
+		}
+
+		active, err := s.sessionService.IsActive(ctx, sessionID, token)
+		if err != nil {
+			return nil, err
+		}
+
+		if !active {
+			return inactive, nil
+		}
+	}
+
+	introspection := &oauth.Introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  user.Username,
+		TokenType: claims.Type,
+		Sub:       claims.UserID,
+		Jti:       claims.ID,
+	}
+
+	if claims.ExpiresAt != nil {
+		introspection.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		introspection.Iat = claims.IssuedAt.Unix()
+	}
+
+	return introspection, nil
+}
+
+// Revoke follows RFC 7009: unknown or foreign tokens are ignored and still answered with 200.
+// Revoking a refresh token ends its session, which also revokes the access token issued with it.
+func (s *oauthService) Revoke(ctx context.Context, client *models.OAuthClient, token string) error {
+	claims, _, err := s.validateAny(ctx, token)
+	if err != nil {
+		if apperr.IsInternal(err) {
+			return err
+		}
+		return nil
+	}
+
+	if claims.ClientID != client.ClientID {
+		return nil
+	}
+
+	if claims.Type == utils.RefreshTokenType {
+		userID, err := claims.UserObjectID()
+		if err != nil {
+			return nil
+		}
+
+		sessionID, err := claims.SessionObjectID()
+		if err != nil {
+			return nil
+		}
+
+		if err := s.sessionService.Revoke(ctx, userID, sessionID); err != nil && apperr.IsInternal(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return s.tokenService.Revoke(ctx, claims)
+}
+
+func (s *oauthService) validateAny(ctx context.Context, token string) (*utils.Claims, *models.User, error) {
+	claims, user, err := s.tokenService.Validate(ctx, token, utils.AccessTokenType)
+	if err == nil || apperr.IsInternal(err) {
+		return claims, user, err
+	}
+
+	return s.tokenService.Validate(ctx, token, utils.RefreshTokenType)
+}
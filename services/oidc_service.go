@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"todolist-auth-fiber/dtos/userDto"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/oauth"
+	"todolist-auth-fiber/utils/oidc"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const OIDCRequestExpiration = time.Minute * 10
+
+type OIDCService interface {
+	Providers() []string
+	Begin(ctx context.Context, provider string, purpose string, userID primitive.ObjectID) (string, error)
+	Login(ctx context.Context, provider string, code string, state string) (*models.User, error)
+	Link(ctx context.Context, user *models.User, provider string, code string, state string) (*models.UserIdentity, error)
+	GetIdentities(ctx context.Context, userID primitive.ObjectID) ([]models.UserIdentity, error)
+	Unlink(ctx context.Context, user *models.User, provider string) error
+}
+
+type oidcService struct {
+	providers  map[string]*oidc.Provider
+	requests   repository.OIDCRequestRepository
+	identities repository.UserIdentityRepository
+	userRepo   repository.UserRepository
+}
+
+func NewOIDCService(
+	providers []*oidc.Provider,
+	requests repository.OIDCRequestRepository,
+	identities repository.UserIdentityRepository,
+	userRepo repository.UserRepository,
+) OIDCService {
+	byName := map[string]*oidc.Provider{}
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &oidcService{
+		providers:  byName,
+		requests:   requests,
+		identities: identities,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Begin stores a fresh state, nonce and PKCE verifier and returns the provider URL to
+// send the user to. Link flows are bound to the account that started them.
+func (s *oidcService) Begin(ctx context.Context, providerName string, purpose string, userID primitive.ObjectID) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", apperr.NotFound(fmt.Sprintf("Unknown provider %s", providerName))
+	}
+
+	state, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	verifier, err := crypto.RandomToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.requests.Create(ctx, &models.OIDCAuthRequest{
+		StateHash:    crypto.HashToken(state),
+		Provider:     providerName,
+		Purpose:      purpose,
+		UserID:       userID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OIDCRequestExpiration),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oauth.PKCEChallenge(verifier))
+	if err != nil {
+		return "", apperr.Upstream(fmt.Sprintf("The %s provider is unavailable, please try again later", providerName), err)
+	}
+
+	return authURL, nil
+}
+
+// Login signs in with a provider account. A known subject logs into its account; otherwise
+// an account with the same verified email is linked, and if there is none one is created.
+func (s *oidcService) Login(ctx context.Context, providerName string, code string, state string) (*models.User, error) {
+	claims, _, err := s.complete(ctx, providerName, models.OIDCPurposeLogin, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.identities.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		user, err := s.userRepo.GetId(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user == nil {
+			return nil, apperr.Unauthorized(fmt.Sprintf("The account linked to this %s login no longer exists", providerName))
+		}
+
+		if err := s.identities.TouchLogin(ctx, identity.ID); err != nil {
+			log.Printf("failed to record OIDC login for identity %s: %v", identity.ID.Hex(), err)
+		}
+
+		return user, nil
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, apperr.Forbidden(fmt.Sprintf("%s did not share a verified email address", providerName))
+	}
+
+	user, err := s.userRepo.GetEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil && !user.EmailVerified {
+		return nil, apperr.Conflict(fmt.Sprintf("An account with this email exists but its email is not verified. Sign in with your password and link %s from your account", providerName))
+	}
+
+	if user == nil {
+		user, err = s.createUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = s.identities.Create(ctx, &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *oidcService) Link(ctx context.Context, user *models.User, providerName string, code string, state string) (*models.UserIdentity, error) {
+	claims, request, err := s.complete(ctx, providerName, models.OIDCPurposeLink, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.UserID != user.ID {
+		return nil, apperr.Validation("Link request invalid or expired")
+	}
+
+	return s.identities.Create(ctx, &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+}
+
+func (s *oidcService) GetIdentities(ctx context.Context, userID primitive.ObjectID) ([]models.UserIdentity, error) {
+	return s.identities.GetAllByUserId(ctx, userID)
+}
+
+// Unlink refuses to remove the last way into an account without a password.
+func (s *oidcService) Unlink(ctx context.Context, user *models.User, providerName string) error {
+	if user.Password == "" {
+		identities, err := s.identities.GetAllByUserId(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(identities) <= 1 {
+			return apperr.Conflict("Set a password with the reset password flow before unlinking your last sign-in provider")
+		}
+	}
+
+	return s.identities.Delete(ctx, user.ID, providerName)
+}
+
+// complete consumes the state of a flow started with Begin and exchanges the code for
+// verified ID token claims.
+func (s *oidcService) complete(ctx context.Context, providerName string, purpose string, code string, state string) (*oidc.IDTokenClaims, *models.OIDCAuthRequest, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, apperr.NotFound(fmt.Sprintf("Unknown provider %s", providerName))
+	}
+
+	request, err := s.requests.Consume(ctx, crypto.HashToken(state))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if request == nil || request.Provider != providerName || request.Purpose != purpose {
+		return nil, nil, apperr.Validation("Sign-in request invalid or expired")
+	}
+
+	claims, err := provider.Exchange(ctx, code, request.CodeVerifier, request.Nonce)
+	if err != nil {
+		log.Printf("OIDC exchange with %s failed: %v", providerName, err)
+		return nil, nil, apperr.Unauthorized(fmt.Sprintf("Sign-in with %s failed", providerName))
+	}
+
+	return claims, request, nil
+}
+
+// createUser creates an account just in time for a first provider login. It has no
+// password until the user sets one through the reset password flow.
+func (s *oidcService) createUser(ctx context.Context, claims *oidc.IDTokenClaims) (*models.User, error) {
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return s.userRepo.Save(ctx, &models.User{
+		Username:        username,
+		Email:           claims.Email,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	})
+}
+
+var usernameValidator = validator.New()
+
+// availableUsername derives a username from the provider claims. The base is cut to 40
+// characters, leaving room for a suffix within the 50 a registration allows.
+func (s *oidcService) availableUsername(ctx context.Context, claims *oidc.IDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.ToValidUTF8(base, "")
+	if runes := []rune(base); len(runes) > 40 {
+		base = string(runes[:40])
+	}
+	for utf8.RuneCountInString(base) < 3 {
+		base += "_"
+	}
+
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		// Same rules as a registration, so the account can be edited like any other.
+		if err := usernameValidator.StructPartial(userDto.CreateUserDTO{Username: username}, "Username"); err != nil {
+			return "", apperr.Unprocessable("The provider username cannot be used, please register with email and password")
+		}
+
+		taken, err := s.userRepo.ExistsByUserName(ctx, username)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return username, nil
+		}
+
+		suffix, err := crypto.RandomToken(3)
+		if err != nil {
+			return "", err
+		}
+		username = base + "_" + suffix
+	}
+
+	return "", apperr.Conflict("Could not pick a free username, please register with email and password")
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"todolist-auth-fiber/dtos/userDto"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/oidc"
+	"unicode/utf8"
+)
+
+type fakeUsernameRepository struct {
+	repository.UserRepository
+	taken map[string]bool
+}
+
+func (r *fakeUsernameRepository) ExistsByUserName(ctx context.Context, username string) (bool, error) {
+	return r.taken[username], nil
+}
+
+func TestOIDCServiceAvailableUsername(t *testing.T) {
+	accented := strings.Repeat("é", 45)
+
+	tests := []struct {
+		name       string
+		claims     oidc.IDTokenClaims
+		taken      []string
+		want       string
+		wantPrefix string
+	}{
+		{
+			name:   "preferred username",
+			claims: oidc.IDTokenClaims{PreferredUsername: "ada", Email: "lovelace@example.com"},
+			want:   "ada",
+		},
+		{
+			name:   "email local part",
+			claims: oidc.IDTokenClaims{Email: "ada.lovelace@example.com"},
+			want:   "ada.lovelace",
+		},
+		{
+			name:   "short name is padded",
+			claims: oidc.IDTokenClaims{PreferredUsername: "é"},
+			want:   "é__",
+		},
+		{
+			name:   "long name is cut on a character boundary",
+			claims: oidc.IDTokenClaims{PreferredUsername: accented},
+			want:   strings.Repeat("é", 40),
+		},
+		{
+			name:   "invalid UTF-8 is dropped",
+			claims: oidc.IDTokenClaims{PreferredUsername: "ada\xff\xfe"},
+			want:   "ada",
+		},
+		{
+			name:       "taken name gets a suffix",
+			claims:     oidc.IDTokenClaims{PreferredUsername: accented},
+			taken:      []string{strings.Repeat("é", 40)},
+			wantPrefix: strings.Repeat("é", 40) + "_",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUsernameRepository{taken: map[string]bool{}}
+			for _, username := range tt.taken {
+				repo.taken[username] = true
+			}
+			service := &oidcService{userRepo: repo}
+
+			got, err := service.availableUsername(context.Background(), &tt.claims)
+			if err != nil {
+				t.Fatalf("availableUsername() error = %v", err)
+			}
+
+			if tt.want != "" && got != tt.want {
+				t.Fatalf("availableUsername() = %q, want %q", got, tt.want)
+			}
+			if tt.wantPrefix != "" && (!strings.HasPrefix(got, tt.wantPrefix) || got == tt.wantPrefix) {
+				t.Fatalf("availableUsername() = %q, want %q and a suffix", got, tt.wantPrefix)
+			}
+
+			if !utf8.ValidString(got) {
+				t.Fatalf("availableUsername() = %q, not valid UTF-8", got)
+			}
+			if err := usernameValidator.StructPartial(userDto.CreateUserDTO{Username: got}, "Username"); err != nil {
+				t.Fatalf("availableUsername() = %q, rejected by the registration rules: %v", got, err)
+			}
+		})
+	}
+}
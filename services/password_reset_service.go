@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+	"todolist-auth-fiber/utils/policy"
+)
+
+const PasswordResetExpiration = time.Minute * 30
+
+type PasswordResetService interface {
+	Request(ctx context.Context, email string) error
+	Confirm(ctx context.Context, token string, password string) (*models.User, error)
+}
+
+type passwordResetService struct {
+	userRepo       repository.UserRepository
+	actionTokens   repository.ActionTokenRepository
+	sessionService SessionService
+	mailer         mailer.Mailer
+	frontendURL    string
+}
+
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	actionTokens repository.ActionTokenRepository,
+	sessionService SessionService,
+	mailer mailer.Mailer,
+	frontendURL string,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepo:       userRepo,
+		actionTokens:   actionTokens,
+		sessionService: sessionService,
+		mailer:         mailer,
+		frontendURL:    frontendURL,
+	}
+}
+
+// Request always answers the same way, whether or not the email belongs to an account.
+func (s *passwordResetService) Request(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	if _, err := s.actionTokens.DeleteAllByUserIdAndPurpose(ctx, user.ID, models.ActionPasswordReset); err != nil {
+		return err
+	}
+
+	token, err := crypto.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.actionTokens.Create(ctx, &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.ActionPasswordReset,
+		TokenHash: crypto.HashToken(token),
+		ExpiresAt: time.Now().Add(PasswordResetExpiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone asked to reset the password of your account. Open the link below to choose a new one:\n\n" +
+			s.frontendURL + "/reset-password?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link expires in 30 minutes. If it was not you, ignore this email.",
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("failed to send password reset email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *passwordResetService) Confirm(ctx context.Context, token string, password string) (*models.User, error) {
+	tokenHash := crypto.HashToken(token)
+
+	// The token is only looked up first so a password rejected by the policy does not burn the link.
+	pending, err := s.actionTokens.GetByHash(ctx, models.ActionPasswordReset, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending == nil {
+		return nil, apperr.Validation("Reset link invalid or expired")
+	}
+
+	user, err := s.userRepo.GetId(ctx, pending.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, apperr.Validation("Reset link invalid or expired")
+	}
+
+	if err := policy.ValidatePassword(password, user.Username, user.Email); err != nil {
+		return nil, err
+	}
+
+	resetToken, err := s.actionTokens.Consume(ctx, models.ActionPasswordReset, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if resetToken == nil {
+		return nil, apperr.Validation("Reset link invalid or expired")
+	}
+
+	passwordHash, err := crypto.Encoder(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.UpdatePassword(ctx, resetToken.UserID, passwordHash); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.actionTokens.DeleteAllByUserIdAndPurpose(ctx, resetToken.UserID, models.ActionPasswordReset); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.sessionService.RevokeAll(ctx, resetToken.UserID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalAccessTokenPrefix marks a bearer token as a personal access token, so it can
+// be told apart from a JWT without parsing it and found by secret scanners.
+const PersonalAccessTokenPrefix = "tdl_pat_"
+
+type PersonalAccessTokenService interface {
+	Create(ctx context.Context, user *models.User, name string, scopes []string, expiresIn time.Duration) (string, *models.PersonalAccessToken, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	Authenticate(ctx context.Context, token string) (*models.PersonalAccessToken, *models.User, error)
+}
+
+type personalAccessTokenService struct {
+	repo     repository.PersonalAccessTokenRepository
+	userRepo repository.UserRepository
+}
+
+func NewPersonalAccessTokenService(repo repository.PersonalAccessTokenRepository, userRepo repository.UserRepository) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// Create returns the plain token next to its record. Only the hash is stored, so this is
+// the only time the token can be shown. A zero expiresIn creates a token that never expires.
+func (s *personalAccessTokenService) Create(ctx context.Context, user *models.User, name string, scopes []string, expiresIn time.Duration) (string, *models.PersonalAccessToken, error) {
+	secret, err := crypto.RandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := PersonalAccessTokenPrefix + secret
+
+	record := &models.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: crypto.HashToken(token),
+		Scopes:    uniqueScopes(scopes),
+	}
+
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		record.ExpiresAt = &expiresAt
+	}
+
+	saved, err := s.repo.Create(ctx, record)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, saved, nil
+}
+
+func (s *personalAccessTokenService) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.PersonalAccessToken, error) {
+	return s.repo.GetAllByUserId(ctx, userID)
+}
+
+func (s *personalAccessTokenService) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *personalAccessTokenService) Authenticate(ctx context.Context, token string) (*models.PersonalAccessToken, *models.User, error) {
+	record, err := s.repo.GetByHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if record == nil {
+		return nil, nil, apperr.Unauthorized("personal access token not found")
+	}
+
+	if record.ExpiresAt != nil && record.ExpiresAt.Before(time.Now()) {
+		return nil, nil, apperr.Unauthorized("personal access token expired")
+	}
+
+	user, err := s.userRepo.GetId(ctx, record.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user == nil {
+		return nil, nil, apperr.Unauthorized("token subject no longer exists")
+	}
+
+	if user.Disabled {
+		return nil, nil, apperr.Unauthorized("account is disabled")
+	}
+
+	if user.PendingDeletion() {
+		return nil, nil, apperr.Unauthorized("account is pending deletion")
+	}
+
+	if err := s.repo.Touch(ctx, record.ID); err != nil {
+		log.Printf("failed to record usage of personal access token %s: %v", record.ID.Hex(), err)
+	}
+
+	return record, user, nil
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecurityEventService interface {
+	Record(ctx context.Context, event *models.SecurityEvent) error
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]models.SecurityEvent, int64, error)
+	GetAll(ctx context.Context, userID *primitive.ObjectID, eventType string, email string, ip string, page, pageSize int) ([]models.SecurityEvent, int64, error)
+}
+
+type securityEventService struct {
+	repo repository.SecurityEventRepository
+}
+
+func NewSecurityEventService(repo repository.SecurityEventRepository) SecurityEventService {
+	return &securityEventService{repo: repo}
+}
+
+func (s *securityEventService) Record(ctx context.Context, event *models.SecurityEvent) error {
+	_, err := s.repo.Create(ctx, event)
+	return err
+}
+
+func (s *securityEventService) GetAllByUserId(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]models.SecurityEvent, int64, error) {
+	return s.repo.GetAll(ctx, &userID, "", "", "", page, pageSize)
+}
+
+func (s *securityEventService) GetAll(ctx context.Context, userID *primitive.ObjectID, eventType string, email string, ip string, page, pageSize int) ([]models.SecurityEvent, int64, error) {
+	return s.repo.GetAll(ctx, userID, eventType, email, ip, page, pageSize)
+}
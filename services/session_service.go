@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/oauth"
+	"todolist-auth-fiber/utils/res"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionService interface {
+	Start(ctx context.Context, user *models.User, userAgent string, ip string) (*res.ResponseToken, error)
+	StartForClient(ctx context.Context, user *models.User, clientID string, scopes []string, userAgent string, ip string) (*res.ResponseToken, error)
+	Refresh(ctx context.Context, user *models.User, sessionID primitive.ObjectID, clientID string, refreshToken string) (*res.ResponseToken, error)
+	GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error)
+	Revoke(ctx context.Context, userID primitive.ObjectID, sessionID primitive.ObjectID) error
+	RevokeAll(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	RevokeOthers(ctx context.Context, userID primitive.ObjectID, currentID primitive.ObjectID) (int64, error)
+	RevokeAllByClientId(ctx context.Context, clientID string) (int64, error)
+	IsActive(ctx context.Context, sessionID primitive.ObjectID, refreshToken string) (bool, error)
+	Touch(ctx context.Context, sessionID primitive.ObjectID) error
+}
+
+type sessionService struct {
+	repo        repository.SessionRepository
+	revokedRepo repository.RevokedTokenRepository
+}
+
+func NewSessionService(repo repository.SessionRepository, revokedRepo repository.RevokedTokenRepository) SessionService {
+	return &sessionService{
+		repo:        repo,
+		revokedRepo: revokedRepo,
+	}
+}
+
+func (s *sessionService) Start(ctx context.Context, user *models.User, userAgent string, ip string) (*res.ResponseToken, error) {
+	return s.StartForClient(ctx, user, "", nil, userAgent, ip)
+}
+
+// StartForClient starts a session on behalf of an OAuth client, whose tokens only carry
+// the granted scopes. An empty clientID starts a regular first-party session.
+func (s *sessionService) StartForClient(ctx context.Context, user *models.User, clientID string, scopes []string, userAgent string, ip string) (*res.ResponseToken, error) {
+	session := models.Session{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ClientID:  clientID,
+		Scopes:    scopes,
+	}
+
+	tokens, accessTokenID, err := generateTokens(user, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(utils.RefreshTokenExpiration)
+	session.RefreshTokenHash = crypto.HashToken(tokens.RefreshToken)
+	session.AccessTokenID = accessTokenID
+	session.ExpiresAt = &expiresAt
+
+	if _, err := s.repo.Create(ctx, &session); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *sessionService) Refresh(ctx context.Context, user *models.User, sessionID primitive.ObjectID, clientID string, refreshToken string) (*res.ResponseToken, error) {
+	session, err := s.repo.GetById(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || session.UserID != user.ID || session.ClientID != clientID {
+		return nil, apperr.Unauthorized("Session not found or already revoked")
+	}
+
+	currentHash := crypto.HashToken(refreshToken)
+	if session.RefreshTokenHash != currentHash {
+		return s.revokeOnReuse(ctx, user.ID)
+	}
+
+	tokens, accessTokenID, err := generateTokens(user, session)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(utils.RefreshTokenExpiration)
+	rotated, err := s.repo.Rotate(ctx, session.ID, currentHash, crypto.HashToken(tokens.RefreshToken), accessTokenID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if rotated == nil {
+		return s.revokeOnReuse(ctx, user.ID)
+	}
+
+	if err := s.denyAccessToken(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *sessionService) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	return s.repo.GetAllByUserId(ctx, userID)
+}
+
+func (s *sessionService) Revoke(ctx context.Context, userID primitive.ObjectID, sessionID primitive.ObjectID) error {
+	session, err := s.repo.GetById(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != userID {
+		return apperr.NotFound("Session not found")
+	}
+
+	if err := s.repo.Delete(ctx, sessionID); err != nil {
+		return err
+	}
+
+	return s.denyAccessToken(ctx, session)
+}
+
+func (s *sessionService) RevokeAll(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.revokeMany(ctx, userID, primitive.NilObjectID)
+}
+
+func (s *sessionService) RevokeOthers(ctx context.Context, userID primitive.ObjectID, currentID primitive.ObjectID) (int64, error) {
+	return s.revokeMany(ctx, userID, currentID)
+}
+
+// IsActive reports whether refreshToken is the current refresh token of a live session.
+func (s *sessionService) IsActive(ctx context.Context, sessionID primitive.ObjectID, refreshToken string) (bool, error) {
+	session, err := s.repo.GetById(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	return session != nil && session.RefreshTokenHash == crypto.HashToken(refreshToken), nil
+}
+
+// Touch moves last_used_at forward when an access token of the session is used, so the
+// session list shows activity and not only refreshes.
+func (s *sessionService) Touch(ctx context.Context, sessionID primitive.ObjectID) error {
+	return s.repo.Touch(ctx, sessionID)
+}
+
+func (s *sessionService) RevokeAllByClientId(ctx context.Context, clientID string) (int64, error) {
+	sessions, err := s.repo.GetAllByClientId(ctx, clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.DeleteAllByClientId(ctx, clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range sessions {
+		if err := s.denyAccessToken(ctx, &sessions[i]); err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *sessionService) revokeMany(ctx context.Context, userID primitive.ObjectID, keepID primitive.ObjectID) (int64, error) {
+	sessions, err := s.repo.GetAllByUserId(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	if keepID.IsZero() {
+		deleted, err = s.repo.DeleteAllByUserId(ctx, userID)
+	} else {
+		deleted, err = s.repo.DeleteAllByUserIdExcept(ctx, userID, keepID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range sessions {
+		if sessions[i].ID == keepID {
+			continue
+		}
+
+		if err := s.denyAccessToken(ctx, &sessions[i]); err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *sessionService) revokeOnReuse(ctx context.Context, userID primitive.ObjectID) (*res.ResponseToken, error) {
+	if _, err := s.RevokeAll(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return nil, apperr.Unauthorized("Refresh token reuse detected, all sessions were revoked")
+}
+
+func (s *sessionService) denyAccessToken(ctx context.Context, session *models.Session) error {
+	if session.AccessTokenID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(utils.AccessTokenExpiration)
+	return s.revokedRepo.Add(ctx, session.AccessTokenID, session.UserID, expiresAt)
+}
+
+func generateTokens(user *models.User, session *models.Session) (*res.ResponseToken, string, error) {
+	accessExpiration := utils.AccessTokenExpiration
+	if session.ClientID != "" {
+		accessExpiration = utils.OAuthAccessTokenExpiration
+	}
+
+	accessClaims := utils.NewClaims(user, session.ID, utils.AccessTokenType, accessExpiration)
+	refreshClaims := utils.NewClaims(user, session.ID, utils.RefreshTokenType, utils.RefreshTokenExpiration)
+
+	if session.ClientID != "" {
+		for _, claims := range []*utils.Claims{accessClaims, refreshClaims} {
+			claims.ClientID = session.ClientID
+			claims.Scope = oauth.FormatScope(session.Scopes)
+		}
+	}
+
+	token, err := utils.SignClaims(accessClaims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, err := utils.SignClaims(refreshClaims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &res.ResponseToken{
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, accessClaims.ID, nil
+}
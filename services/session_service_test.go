@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/keys"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	utils.ConfigureKeys(keys.NewHMAC("test", []byte("session-service-test-secret")))
+	os.Exit(m.Run())
+}
+
+type fakeSessionRepository struct {
+	sessions map[primitive.ObjectID]*models.Session
+	// staleRotate makes Rotate lose the race against a concurrent refresh.
+	staleRotate bool
+}
+
+func newFakeSessionRepository(sessions ...models.Session) *fakeSessionRepository {
+	r := &fakeSessionRepository{sessions: map[primitive.ObjectID]*models.Session{}}
+	for i := range sessions {
+		r.sessions[sessions[i].ID] = &sessions[i]
+	}
+	return r
+}
+
+func (r *fakeSessionRepository) EnsureIndexes(ctx context.Context) error { return nil }
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
+	r.sessions[session.ID] = session
+	return session, nil
+}
+
+func (r *fakeSessionRepository) GetById(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepository) GetAllByUserId(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	sessions := []models.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, currentHash string, nextHash string, accessTokenID string, expiresAt time.Time) (*models.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok || r.staleRotate || session.RefreshTokenHash != currentHash {
+		return nil, nil
+	}
+	session.RefreshTokenHash = nextHash
+	session.AccessTokenID = accessTokenID
+	session.ExpiresAt = &expiresAt
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepository) Touch(ctx context.Context, id primitive.ObjectID) error { return nil }
+
+func (r *fakeSessionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *fakeSessionRepository) DeleteAllByUserId(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.DeleteAllByUserIdExcept(ctx, userID, primitive.NilObjectID)
+}
+
+func (r *fakeSessionRepository) DeleteAllByUserIdExcept(ctx context.Context, userID primitive.ObjectID, keepID primitive.ObjectID) (int64, error) {
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != keepID {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *fakeSessionRepository) GetAllByClientId(ctx context.Context, clientID string) ([]models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessionRepository) DeleteAllByClientId(ctx context.Context, clientID string) (int64, error) {
+	return 0, nil
+}
+
+type fakeRevokedTokenRepository struct {
+	revoked map[string]bool
+}
+
+func (r *fakeRevokedTokenRepository) EnsureIndexes(ctx context.Context) error { return nil }
+
+func (r *fakeRevokedTokenRepository) Add(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time) error {
+	r.revoked[jti] = true
+	return nil
+}
+
+func (r *fakeRevokedTokenRepository) Exists(ctx context.Context, jti string) (bool, error) {
+	return r.revoked[jti], nil
+}
+
+func TestSessionServiceRefresh(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID(), Email: "ada@example.com", Username: "ada"}
+	otherUser := primitive.NewObjectID()
+
+	const refreshToken = "current-refresh-token"
+
+	tests := []struct {
+		name        string
+		userID      primitive.ObjectID
+		sessionID   func(current primitive.ObjectID) primitive.ObjectID
+		clientID    string
+		token       string
+		staleRotate bool
+		wantRotated bool
+		wantRevoked bool
+	}{
+		{
+			name:        "current token rotates",
+			userID:      user.ID,
+			token:       refreshToken,
+			wantRotated: true,
+		},
+		{
+			name:        "reused token revokes every session",
+			userID:      user.ID,
+			token:       "previous-refresh-token",
+			wantRevoked: true,
+		},
+		{
+			name:        "lost rotation race revokes every session",
+			userID:      user.ID,
+			token:       refreshToken,
+			staleRotate: true,
+			wantRevoked: true,
+		},
+		{
+			name:      "unknown session",
+			userID:    user.ID,
+			sessionID: func(primitive.ObjectID) primitive.ObjectID { return primitive.NewObjectID() },
+			token:     refreshToken,
+		},
+		{
+			name:   "session of another user",
+			userID: otherUser,
+			token:  refreshToken,
+		},
+		{
+			name:     "session of another client",
+			userID:   user.ID,
+			clientID: "other-client",
+			token:    refreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := models.Session{
+				ID:               primitive.NewObjectID(),
+				UserID:           tt.userID,
+				RefreshTokenHash: crypto.HashToken(refreshToken),
+				AccessTokenID:    "current-access-jti",
+			}
+			sibling := models.Session{
+				ID:               primitive.NewObjectID(),
+				UserID:           tt.userID,
+				RefreshTokenHash: crypto.HashToken("sibling-refresh-token"),
+				AccessTokenID:    "sibling-access-jti",
+			}
+
+			repo := newFakeSessionRepository(current, sibling)
+			repo.staleRotate = tt.staleRotate
+			revoked := &fakeRevokedTokenRepository{revoked: map[string]bool{}}
+			service := NewSessionService(repo, revoked)
+
+			sessionID := current.ID
+			if tt.sessionID != nil {
+				sessionID = tt.sessionID(current.ID)
+			}
+
+			tokens, err := service.Refresh(context.Background(), user, sessionID, tt.clientID, tt.token)
+
+			if !tt.wantRotated {
+				if !apperr.Is(err, apperr.KindUnauthorized) {
+					t.Fatalf("Refresh() error = %v, want Unauthorized", err)
+				}
+				if tokens != nil {
+					t.Fatalf("Refresh() returned tokens on failure")
+				}
+
+				_, stillThere := repo.sessions[sibling.ID]
+				if tt.wantRevoked == stillThere {
+					t.Fatalf("sibling session kept = %v, want revoked = %v", stillThere, tt.wantRevoked)
+				}
+				if tt.wantRevoked != revoked.revoked["sibling-access-jti"] {
+					t.Fatalf("sibling access token denied = %v, want %v", revoked.revoked["sibling-access-jti"], tt.wantRevoked)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+
+			rotated := repo.sessions[current.ID]
+			if rotated.RefreshTokenHash != crypto.HashToken(tokens.RefreshToken) {
+				t.Fatalf("session does not hold the new refresh token")
+			}
+			if tokens.RefreshToken == refreshToken {
+				t.Fatalf("refresh token was not rotated")
+			}
+			if !revoked.revoked["current-access-jti"] {
+				t.Fatalf("previous access token was not denied")
+			}
+			if revoked.revoked["sibling-access-jti"] {
+				t.Fatalf("sibling access token was denied")
+			}
+
+			claims, err := utils.ParseToken(tokens.Token, utils.AccessTokenType)
+			if err != nil {
+				t.Fatalf("new access token does not parse: %v", err)
+			}
+			if claims.ID != rotated.AccessTokenID || claims.SessionID != current.ID.Hex() {
+				t.Fatalf("new access token jti %q sid %q, session has %q %q", claims.ID, claims.SessionID, rotated.AccessTokenID, current.ID.Hex())
+			}
+
+			if _, err := service.Refresh(context.Background(), user, current.ID, "", refreshToken); !apperr.Is(err, apperr.KindUnauthorized) {
+				t.Fatalf("second use of the old refresh token: error = %v, want Unauthorized", err)
+			}
+			if len(repo.sessions) != 0 {
+				t.Fatalf("reuse after rotation kept %d sessions", len(repo.sessions))
+			}
+		})
+	}
+}
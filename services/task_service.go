@@ -2,22 +2,21 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"time"
 	taskdto "todolist-auth-fiber/dtos/taskDto"
 	"todolist-auth-fiber/models"
 	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TaskService interface {
-	GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, int, error)
-	Delete(ctx context.Context, id primitive.ObjectID) (int, error)
-	Create(ctx context.Context, userID primitive.ObjectID, dto taskdto.CreateTaskDTO) (*models.Todo, int, error)
-	ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, int, error)
-	Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, int, error)
-	DeleteAllByUserId(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	Create(ctx context.Context, userID primitive.ObjectID, dto taskdto.CreateTaskDTO) (*models.Todo, error)
+	ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, error)
+	Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, error)
 	GetAll(
 		ctx context.Context,
 		userID primitive.ObjectID,
@@ -38,58 +37,58 @@ func NewTaskService(repo repository.TaskRepository) TaskService {
 	}
 }
 
-func (s *taskService) GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, int, error) {
-	task, code, err := s.repo.GetById(ctx, id)
+func (s *taskService) GetById(ctx context.Context, id primitive.ObjectID) (*models.Todo, error) {
+	task, err := s.repo.GetById(ctx, id)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
 	if task == nil {
-		return nil, 404, fmt.Errorf("Task not found")
+		return nil, apperr.NotFound("Task not found")
 	}
 
-	return task, 200, nil
+	return task, nil
 }
 
-func (s *taskService) Delete(ctx context.Context, id primitive.ObjectID) (int, error) {
-	code, err := s.repo.Delete(ctx, id)
+func (s *taskService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	err := s.repo.Delete(ctx, id)
 	if err != nil {
-		return code, err
+		return err
 	}
 
-	return 200, nil
+	return nil
 }
 
-func (s *taskService) Create(ctx context.Context, userID primitive.ObjectID, dto taskdto.CreateTaskDTO) (*models.Todo, int, error) {
+func (s *taskService) Create(ctx context.Context, userID primitive.ObjectID, dto taskdto.CreateTaskDTO) (*models.Todo, error) {
 	var task models.Todo
 
 	task.Title = dto.Title
 	task.Discription = dto.Discription
 
-	saved, code, err := s.repo.Create(ctx, userID, task)
+	saved, err := s.repo.Create(ctx, userID, task)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
-	return saved, code, err
+	return saved, err
 }
 
-func (s *taskService) ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, int, error) {
-	taskChanged, code, err := s.repo.ChangeStatus(ctx, id, task)
+func (s *taskService) ChangeStatus(ctx context.Context, id primitive.ObjectID, task *models.Todo) (*models.Todo, error) {
+	taskChanged, err := s.repo.ChangeStatus(ctx, id, task)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
-	return taskChanged, code, nil
+	return taskChanged, nil
 }
 
-func (s *taskService) Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, int, error) {
-	updated, code, err := s.repo.Update(ctx, id, dto)
+func (s *taskService) Update(ctx context.Context, id primitive.ObjectID, dto taskdto.UpdateTaskDTO) (*models.Todo, error) {
+	updated, err := s.repo.Update(ctx, id, dto)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
-	return updated, code, nil
+	return updated, nil
 }
 
 func (s *taskService) GetAll(
@@ -108,12 +107,3 @@ func (s *taskService) GetAll(
 
 	return tasks, total, nil
 }
-
-func (s *taskService) DeleteAllByUserId(ctx context.Context, userId primitive.ObjectID) (int64, error) {
-	result, err := s.repo.DeleteAllByUserId(ctx, userId)
-	if err != nil {
-		return 0, err
-	}
-
-	return result, nil
-}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils"
+	"todolist-auth-fiber/utils/apperr"
+)
+
+type TokenService interface {
+	Validate(ctx context.Context, tokenString string, tokenType string) (*utils.Claims, *models.User, error)
+	Revoke(ctx context.Context, claims *utils.Claims) error
+}
+
+type tokenService struct {
+	userRepo    repository.UserRepository
+	revokedRepo repository.RevokedTokenRepository
+}
+
+func NewTokenService(userRepo repository.UserRepository, revokedRepo repository.RevokedTokenRepository) TokenService {
+	return &tokenService{
+		userRepo:    userRepo,
+		revokedRepo: revokedRepo,
+	}
+}
+
+func (s *tokenService) Validate(ctx context.Context, tokenString string, tokenType string) (*utils.Claims, *models.User, error) {
+	claims, err := utils.ParseToken(tokenString, tokenType)
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.KindUnauthorized, "invalid token", err)
+	}
+
+	userID, err := claims.UserObjectID()
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.KindUnauthorized, "invalid token", err)
+	}
+
+	revoked, err := s.revokedRepo.Exists(ctx, claims.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if revoked {
+		return nil, nil, apperr.Unauthorized("token has been revoked")
+	}
+
+	user, err := s.userRepo.GetId(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user == nil {
+		return nil, nil, apperr.Unauthorized("token subject no longer exists")
+	}
+
+	if user.Disabled {
+		return nil, nil, apperr.Unauthorized("account is disabled")
+	}
+
+	if user.PendingDeletion() {
+		return nil, nil, apperr.Unauthorized("account is pending deletion")
+	}
+
+	if claims.CredentialsVersion != user.CredentialsVersion {
+		return nil, nil, apperr.Unauthorized("token was issued before the last credentials change")
+	}
+
+	if claims.IsImpersonation() {
+		if err := s.validateActor(ctx, claims); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return claims, user, nil
+}
+
+// validateActor stops an impersonation token as soon as the admin behind it loses the
+// permission or the account.
+func (s *tokenService) validateActor(ctx context.Context, claims *utils.Claims) error {
+	actorID, err := claims.ActorObjectID()
+	if err != nil {
+		return apperr.Wrap(apperr.KindUnauthorized, "invalid token", err)
+	}
+
+	actor, err := s.userRepo.GetId(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	if actor == nil || actor.Disabled || actor.PendingDeletion() || !actor.Can(models.PermissionImpersonate) {
+		return apperr.Unauthorized("impersonating admin is no longer allowed to act")
+	}
+
+	return nil
+}
+
+func (s *tokenService) Revoke(ctx context.Context, claims *utils.Claims) error {
+	userID, err := claims.UserObjectID()
+	if err != nil {
+		return apperr.Wrap(apperr.KindValidation, "invalid token", err)
+	}
+
+	expiresAt := time.Now().Add(utils.AccessTokenExpiration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return s.revokedRepo.Add(ctx, claims.ID, userID, expiresAt)
+}
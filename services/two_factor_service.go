@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+	totpSkew           = 1
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, user *models.User) (*TOTPEnrollment, error)
+	Activate(ctx context.Context, user *models.User, code string) ([]string, error)
+	Disable(ctx context.Context, user *models.User, code string) error
+	Verify(ctx context.Context, user *models.User, code string) error
+}
+
+type twoFactorService struct {
+	userRepo repository.UserRepository
+	issuer   string
+}
+
+func NewTwoFactorService(userRepo repository.UserRepository, issuer string) TwoFactorService {
+	if issuer == "" {
+		issuer = "todolist-auth-fiber"
+	}
+
+	return &twoFactorService{
+		userRepo: userRepo,
+		issuer:   issuer,
+	}
+}
+
+func (s *twoFactorService) Enroll(ctx context.Context, user *models.User) (*TOTPEnrollment, error) {
+	if user.TOTPEnabled {
+		return nil, apperr.Conflict("Two-factor authentication already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) Activate(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, apperr.Conflict("Two-factor authentication already enabled")
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, apperr.Validation("Two-factor enrollment not started")
+	}
+
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, apperr.Validation("Two-factor code invalid")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, counter, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return apperr.Validation("Two-factor authentication is not enabled")
+	}
+
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.userRepo.DisableTOTP(ctx, user.ID)
+}
+
+// Verify accepts either a current TOTP code or one of the unused recovery codes.
+func (s *twoFactorService) Verify(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return apperr.Validation("Two-factor authentication is not enabled")
+	}
+
+	if counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); ok {
+		used, err := s.userRepo.UseTOTPCounter(ctx, user.ID, counter)
+		if err != nil {
+			return err
+		}
+
+		if !used {
+			return apperr.Unauthorized("Two-factor code already used")
+		}
+
+		return nil
+	}
+
+	used, err := s.userRepo.UseRecoveryCode(ctx, user.ID, crypto.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return apperr.Unauthorized("Two-factor code invalid")
+	}
+
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(secret[:10])
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, crypto.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todolist-auth-fiber/models"
+	repository "todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/apperr"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/totp"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeTOTPUserRepository keeps the last used step and the recovery codes like the Mongo
+// repository does. Other methods are not used by Verify.
+type fakeTOTPUserRepository struct {
+	repository.UserRepository
+	lastCounter   *int64
+	recoveryCodes map[string]bool
+}
+
+func (r *fakeTOTPUserRepository) UseTOTPCounter(ctx context.Context, id primitive.ObjectID, counter int64) (bool, error) {
+	if r.lastCounter != nil && *r.lastCounter >= counter {
+		return false, nil
+	}
+	r.lastCounter = &counter
+	return true, nil
+}
+
+func (r *fakeTOTPUserRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	if !r.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	delete(r.recoveryCodes, codeHash)
+	return true, nil
+}
+
+func TestTwoFactorServiceVerifyReplay(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := totp.Counter(time.Now())
+	code := func(step int64) string {
+		c, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		codes []string
+		want  []bool
+	}{
+		{
+			name:  "same code twice",
+			codes: []string{code(now), code(now)},
+			want:  []bool{true, false},
+		},
+		{
+			name:  "older step after a newer one",
+			codes: []string{code(now), code(now - 1)},
+			want:  []bool{true, false},
+		},
+		{
+			name:  "newer step after an older one",
+			codes: []string{code(now - 1), code(now)},
+			want:  []bool{true, true},
+		},
+		{
+			name:  "recovery code only once",
+			codes: []string{"abcde-12345", "ABCDE-12345"},
+			want:  []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTOTPUserRepository{
+				recoveryCodes: map[string]bool{crypto.HashToken(normalizeRecoveryCode("abcde-12345")): true},
+			}
+			service := NewTwoFactorService(repo, "")
+			user := &models.User{ID: primitive.NewObjectID(), TOTPEnabled: true, TOTPSecret: secret}
+
+			for i, c := range tt.codes {
+				err := service.Verify(context.Background(), user, c)
+				if tt.want[i] && err != nil {
+					t.Fatalf("code %d: Verify() error = %v, want accepted", i, err)
+				}
+				if !tt.want[i] && !apperr.Is(err, apperr.KindUnauthorized) {
+					t.Fatalf("code %d: Verify() error = %v, want Unauthorized", i, err)
+				}
+			}
+		})
+	}
+}
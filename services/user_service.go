@@ -1,126 +1,197 @@
 package services
 
 import (
+	"todolist-auth-fiber/utils/apperr"
 	"context"
-	"fmt"
+	"log"
+	"time"
 	"todolist-auth-fiber/dtos/userDto"
 	"todolist-auth-fiber/models"
 	"todolist-auth-fiber/repositories"
+	"todolist-auth-fiber/utils/crypto"
+	"todolist-auth-fiber/utils/mailer"
+	"todolist-auth-fiber/utils/policy"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService interface {
-	GetById(ctx context.Context, id primitive.ObjectID) (*models.User, int, error)
-	GetByEmail(ctx context.Context, email string) (*models.User, int, error)
-	Delete(ctx context.Context, user *models.User) (int, error)
-	Save(ctx context.Context, dto userDto.CreateUserDTO) (*models.User, int, error)
-	ExistsByEmail(ctx context.Context, email string) (bool, int, error)
-	Update(ctx context.Context, user *models.User, dto userDto.UpdateUserDTO) (*models.User, uint, error)
-	ExistsByUserName(ctx context.Context, UserName string) (bool, int, error)
-	SetRefreshToken(ctx context.Context, user *models.User, refreshToken string) (*models.User, int, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Save(ctx context.Context, dto userDto.CreateUserDTO) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	Update(ctx context.Context, user *models.User, dto userDto.UpdateUserDTO) (*models.User, error)
+	ExistsByUserName(ctx context.Context, UserName string) (bool, error)
+	RehashPassword(ctx context.Context, user *models.User, password string) error
+	ChangePassword(ctx context.Context, user *models.User, currentPassword string, newPassword string) (*models.User, error)
+	NotifyExistingAccount(ctx context.Context, email string) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo         repository.UserRepository
+	actionTokens repository.ActionTokenRepository
+	mailer       mailer.Mailer
+	frontendURL  string
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
+func NewUserService(repo repository.UserRepository, actionTokens repository.ActionTokenRepository, mailer mailer.Mailer, frontendURL string) UserService {
 	return &userService {
-		repo: repo,
+		repo:         repo,
+		actionTokens: actionTokens,
+		mailer:       mailer,
+		frontendURL:  frontendURL,
 	}
 }
 
-func (u *userService) GetById(ctx context.Context, id primitive.ObjectID) (*models.User, int, error) {
-	user, code, err := u.repo.GetId(ctx, id)
+func (u *userService) GetById(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := u.repo.GetId(ctx, id)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
 	if user == nil {
-		return nil, code, fmt.Errorf("User not found")
+		return nil, apperr.NotFound("User not found")
 	}
 
-	return user, code, nil
+	return user, nil
 }
 
-func (u *userService) GetByEmail(ctx context.Context, email string) (*models.User, int, error) {
-	user, code, err := u.repo.GetEmail(ctx, email)
+func (u *userService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := u.repo.GetEmail(ctx, email)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
 	if user == nil {
-		return nil, 404, fmt.Errorf("User not found")
+		return nil, apperr.NotFound("User not found")
 	}
 
-	return user, 200, nil
+	return user, nil
 }
 
-func (u *userService) Delete(ctx context.Context, user *models.User) (int, error) { 
-	code, err := u.repo.Delete(ctx, user.ID)
-	if err != nil {
-		return code, err
-	}
-
-	return code, nil
-}
-
-func (u *userService) Save(ctx context.Context, dto userDto.CreateUserDTO) (*models.User, int, error) {
+func (u *userService) Save(ctx context.Context, dto userDto.CreateUserDTO) (*models.User, error) {
 	var user models.User
 
 	user.Username = dto.Username
 	user.Email = dto.Email
 	user.Password = dto.Password
 
-	saved, code, err := u.repo.Save(ctx, &user)
+	saved, err := u.repo.Save(ctx, &user)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
-	return saved, code, nil
+	return saved, nil
 } 
 
-func (u *userService) ExistsByEmail(ctx context.Context, email string) (bool, int, error) {
+func (u *userService) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	if email == "" {
-		return false, 400, fmt.Errorf("Email is required")
+		return false, apperr.Validation("Email is required")
 	}
 
-	check, code, err := u.repo.ExistsByEmail(ctx, email)
+	check, err := u.repo.ExistsByEmail(ctx, email)
 	if err != nil {
-		return false, code, err
+		return false, err
 	}
 
-	return check, code, nil
+	if check {
+		return true, nil
+	}
+
+	// An address waiting for an email change confirmation is taken as well.
+	return u.actionTokens.ExistsByEmail(ctx, models.ActionEmailChange, email)
 }
 
-func (u *userService) ExistsByUserName(ctx context.Context, UserName string) (bool, int, error) {
+func (u *userService) ExistsByUserName(ctx context.Context, UserName string) (bool, error) {
 	if UserName == "" {
-		return false, 400, fmt.Errorf("UserName is required")
+		return false, apperr.Validation("UserName is required")
 	}
 
-	check, code, err := u.repo.ExistsByUserName(ctx, UserName)
+	check, err := u.repo.ExistsByUserName(ctx, UserName)
 	if err != nil {
-		return false, code, err
+		return false, err
 	}
 
-	return check, code, nil
+	return check, nil
 }
 
-func (u *userService) Update(ctx context.Context, user *models.User, dto userDto.UpdateUserDTO) (*models.User, uint, error) {
-	updated, code, err := u.repo.Update(ctx, user.ID, dto)
+func (u *userService) Update(ctx context.Context, user *models.User, dto userDto.UpdateUserDTO) (*models.User, error) {
+	updated, err := u.repo.Update(ctx, user.ID, dto)
 	if err != nil {
-		return nil, code, err
+		return nil, err
 	}
 
-	return updated, code, nil
+	return updated, nil
 }
 
-func (u *userService) SetRefreshToken(ctx context.Context, user *models.User, refreshToken string) (*models.User, int, error) {
-	userSaved, code, err := u.repo.SetRefreshToken(ctx, user.ID, refreshToken)
+func (u *userService) RehashPassword(ctx context.Context, user *models.User, password string) error {
+	if !crypto.NeedsRehash(user.Password) {
+		return nil
+	}
+
+	passwordHash, err := crypto.Encoder(password)
 	if err != nil {
-		return nil, code, err
+		return err
+	}
+
+	return u.repo.SetPasswordHash(ctx, user.ID, user.Password, passwordHash)
+}
+
+func (u *userService) ChangePassword(ctx context.Context, user *models.User, currentPassword string, newPassword string) (*models.User, error) {
+	if !crypto.Compare(currentPassword, user.Password) {
+		return nil, apperr.Forbidden("Current password is incorrect")
+	}
+
+	if currentPassword == newPassword {
+		return nil, policy.Rejected([]policy.Violation{{
+			Code:    "same_as_current",
+			Message: "New password must be different from the current one",
+		}})
+	}
+
+	if err := policy.ValidatePassword(newPassword, user.Username, user.Email); err != nil {
+		return nil, err
 	}
 
-	return userSaved, 200, nil
-}
\ No newline at end of file
+	passwordHash, err := crypto.Encoder(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.UpdatePassword(ctx, user.ID, passwordHash)
+}
+
+// NotifyExistingAccount tells the owner of email that someone tried to sign up with it.
+// Silent signup sends it instead of answering 409, so only the owner learns the email is
+// taken.
+func (u *userService) NotifyExistingAccount(ctx context.Context, email string) error {
+	user, err := u.repo.GetEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Someone tried to sign up with your email",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone tried to create an account with this email, but you already have one.\n" +
+			"If it was you, log in at " + u.frontendURL + "/login or reset your password at " +
+			u.frontendURL + "/forgot-password.\n\n" +
+			"If it was not you, you can ignore this email.",
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := u.mailer.Send(ctx, msg); err != nil {
+			log.Printf("failed to send signup notice to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+
+	return nil
+}
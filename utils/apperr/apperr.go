@@ -0,0 +1,163 @@
+// Package apperr is the error model shared by repositories, services and handlers.
+//
+// An *Error carries a Kind, which decides the HTTP status, a message that is safe to
+// show to the client, and optionally the error that caused it. Any error that is not an
+// *Error is treated as Internal: it is logged and the client only gets a generic message.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Kind uint8
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindGone
+	KindUnprocessable
+	KindTooManyRequests
+	KindUpstream
+)
+
+// InternalMessage is what clients see for every internal error.
+const InternalMessage = "Error in server! Please try again later"
+
+var statuses = map[Kind]int{
+	KindInternal:        http.StatusInternalServerError,
+	KindValidation:      http.StatusBadRequest,
+	KindUnauthorized:    http.StatusUnauthorized,
+	KindForbidden:       http.StatusForbidden,
+	KindNotFound:        http.StatusNotFound,
+	KindConflict:        http.StatusConflict,
+	KindGone:            http.StatusGone,
+	KindUnprocessable:   http.StatusUnprocessableEntity,
+	KindTooManyRequests: http.StatusTooManyRequests,
+	KindUpstream:        http.StatusBadGateway,
+}
+
+// Status is the HTTP status the kind is answered with.
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+type Error struct {
+	Kind    Kind
+	Message string
+	// Details is sent as the response body, e.g. the list of invalid fields.
+	Details interface{}
+	// Challenge is sent as the WWW-Authenticate header.
+	Challenge string
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of e that sends details as the response body.
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// WithChallenge returns a copy of e that sends challenge as the WWW-Authenticate header.
+func (e *Error) WithChallenge(challenge string) *Error {
+	copied := *e
+	copied.Challenge = challenge
+	return &copied
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap keeps err as the cause, for logs and errors.Is, without sending it to the client.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func Validation(message string) *Error {
+	return New(KindValidation, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(KindUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(KindForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+func Gone(message string) *Error {
+	return New(KindGone, message)
+}
+
+func Unprocessable(message string) *Error {
+	return New(KindUnprocessable, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(KindTooManyRequests, message)
+}
+
+func Upstream(message string, err error) *Error {
+	return Wrap(KindUpstream, message, err)
+}
+
+func Internal(err error) *Error {
+	return Wrap(KindInternal, InternalMessage, err)
+}
+
+// As returns the *Error in err's chain, or an Internal one wrapping err.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	return Internal(err)
+}
+
+func KindOf(err error) Kind {
+	return As(err).Kind
+}
+
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// IsInternal reports whether err would be answered with a 5xx status.
+func IsInternal(err error) bool {
+	return err != nil && KindOf(err).Status() >= http.StatusInternalServerError
+}
@@ -0,0 +1,98 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestKindStatus(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		want int
+	}{
+		{KindInternal, http.StatusInternalServerError},
+		{KindValidation, http.StatusBadRequest},
+		{KindUnauthorized, http.StatusUnauthorized},
+		{KindForbidden, http.StatusForbidden},
+		{KindNotFound, http.StatusNotFound},
+		{KindConflict, http.StatusConflict},
+		{KindGone, http.StatusGone},
+		{KindUnprocessable, http.StatusUnprocessableEntity},
+		{KindTooManyRequests, http.StatusTooManyRequests},
+		{KindUpstream, http.StatusBadGateway},
+		{Kind(200), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := tt.kind.Status(); got != tt.want {
+			t.Errorf("Kind(%d).Status() = %d, want %d", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestAs(t *testing.T) {
+	cause := errors.New("connection refused")
+	notFound := NotFound("Task not found")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantKind    Kind
+		wantMessage string
+		internal    bool
+	}{
+		{name: "typed", err: notFound, wantKind: KindNotFound, wantMessage: "Task not found"},
+		{name: "typed wrapped by fmt", err: fmt.Errorf("load task: %w", notFound), wantKind: KindNotFound, wantMessage: "Task not found"},
+		{name: "plain error", err: cause, wantKind: KindInternal, wantMessage: InternalMessage, internal: true},
+		{name: "upstream", err: Upstream("Provider unavailable", cause), wantKind: KindUpstream, wantMessage: "Provider unavailable", internal: true},
+		{name: "validation with cause", err: Wrap(KindValidation, "Id invalid", cause), wantKind: KindValidation, wantMessage: "Id invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := As(tt.err)
+			if got.Kind != tt.wantKind || got.Message != tt.wantMessage {
+				t.Fatalf("As() = kind %d message %q, want kind %d message %q", got.Kind, got.Message, tt.wantKind, tt.wantMessage)
+			}
+			if !Is(tt.err, tt.wantKind) {
+				t.Fatalf("Is(err, %d) = false", tt.wantKind)
+			}
+			if IsInternal(tt.err) != tt.internal {
+				t.Fatalf("IsInternal() = %v, want %v", IsInternal(tt.err), tt.internal)
+			}
+		})
+	}
+
+	if Is(nil, KindInternal) || IsInternal(nil) {
+		t.Fatal("a nil error is reported as an error kind")
+	}
+}
+
+func TestWrapKeepsCause(t *testing.T) {
+	cause := errors.New("duplicate key")
+	err := Wrap(KindConflict, "Email already in use", cause)
+
+	if !errors.Is(err, cause) {
+		t.Fatal("errors.Is() does not find the cause")
+	}
+	if got := err.Error(); got != "Email already in use: duplicate key" {
+		t.Fatalf("Error() = %q", got)
+	}
+	if got := Internal(cause).Error(); got != InternalMessage+": duplicate key" {
+		t.Fatalf("Internal().Error() = %q", got)
+	}
+}
+
+func TestWithDetailsCopies(t *testing.T) {
+	base := Forbidden("You do not have permission to do this")
+	detailed := base.WithDetails("tasks:read")
+
+	if base.Details != nil {
+		t.Fatal("WithDetails() changed the receiver")
+	}
+	if detailed.Details != "tasks:read" || detailed.Kind != KindForbidden || detailed.Message != base.Message {
+		t.Fatalf("WithDetails() = %+v", detailed)
+	}
+}
@@ -0,0 +1,127 @@
+// Package archive writes zip archives of JSON and CSV files one entry at a time, so large
+// collections can be streamed from a cursor instead of being held in memory.
+package archive
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+type Writer struct {
+	zip      *zip.Writer
+	modified time.Time
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w), modified: time.Now()}
+}
+
+// JSON writes v as an indented JSON file.
+func (w *Writer) JSON(name string, v interface{}) error {
+	entry, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// JSONArray writes a JSON array with one element per call to emit.
+func (w *Writer) JSONArray(name string, each func(emit func(v interface{}) error) error) error {
+	entry, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(entry, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err = each(func(v interface{}) error {
+		item, err := json.MarshalIndent(v, "  ", "  ")
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n  "
+		if first {
+			separator, first = "\n  ", false
+		}
+
+		if _, err := io.WriteString(entry, separator); err != nil {
+			return err
+		}
+
+		_, err = entry.Write(item)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	closing := "\n]\n"
+	if first {
+		closing = "]\n"
+	}
+
+	_, err = io.WriteString(entry, closing)
+	return err
+}
+
+// CSV writes a CSV file with header as its first row and one row per call to emit.
+func (w *Writer) CSV(name string, header []string, each func(emit func(record ...string) error) error) error {
+	entry, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(entry)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err = each(func(record ...string) error {
+		for i := range record {
+			record[i] = escapeFormula(record[i])
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Close writes the zip central directory. The underlying writer is not closed.
+func (w *Writer) Close() error {
+	return w.zip.Close()
+}
+
+func (w *Writer) create(name string) (io.Writer, error) {
+	return w.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: w.modified,
+	})
+}
+
+// escapeFormula keeps spreadsheet programs from evaluating user text as a formula. Some
+// of them skip leading spaces before looking for the formula sign.
+func escapeFormula(value string) string {
+	trimmed := strings.TrimLeft(value, " ")
+	if trimmed != "" && strings.ContainsRune("=+-@\t\r", rune(trimmed[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+)
+
+func TestWriterCSVEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain text", value: "buy milk", want: "buy milk"},
+		{name: "empty", value: "", want: ""},
+		{name: "sign inside the text", value: "a=b+c", want: "a=b+c"},
+		{name: "equals", value: "=HYPERLINK(\"http://evil.test\")", want: "'=HYPERLINK(\"http://evil.test\")"},
+		{name: "plus", value: "+1+1", want: "'+1+1"},
+		{name: "minus", value: "-2+3", want: "'-2+3"},
+		{name: "at", value: "@SUM(A1)", want: "'@SUM(A1)"},
+		{name: "tab", value: "\t=1", want: "'\t=1"},
+		{name: "carriage return", value: "\r=1", want: "'\r=1"},
+		{name: "leading spaces", value: "  =1+1", want: "'  =1+1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+
+			err := w.CSV("tasks.csv", []string{"id", "title"}, func(emit func(record ...string) error) error {
+				return emit("1", tt.value)
+			})
+			if err != nil {
+				t.Fatalf("CSV() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			records := readCSV(t, buf.Bytes(), "tasks.csv")
+			if len(records) != 2 {
+				t.Fatalf("got %d rows, want header and one record", len(records))
+			}
+			if records[0][1] != "title" {
+				t.Fatalf("header = %q, want it untouched", records[0])
+			}
+			if got := records[1][1]; got != tt.want {
+				t.Fatalf("cell = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func readCSV(t *testing.T, archive []byte, name string) [][]string {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("archive does not open: %v", err)
+	}
+
+	entry, err := reader.Open(name)
+	if err != nil {
+		t.Fatalf("archive has no %s: %v", name, err)
+	}
+	defer entry.Close()
+
+	content, err := io.ReadAll(entry)
+	if err != nil {
+		t.Fatalf("%s does not read: %v", name, err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		t.Fatalf("%s is not valid CSV: %v", name, err)
+	}
+
+	return records
+}
@@ -1,29 +1,81 @@
 package crypto
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
+	"sync"
 )
 
+var dummy struct {
+	sync.Mutex
+	hasher Hasher
+	hash   string
+}
+
 func Encoder(password string) (string, error) {
 	if password == "" {
 		return "", fmt.Errorf("Password is Required")
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 8)
+	hash, err := defaultHasher.Hash(password)
 	if err != nil {
 		return "", fmt.Errorf("Error the encoder password")
 	}
 
-	return string(hash), nil
+	return hash, nil
 }
 
 func Compare(password string, passwordHash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
-	if err != nil {
+	hasher := findHasher(passwordHash)
+	if hasher == nil {
 		return false
 	}
 
-	return true
-}
\ No newline at end of file
+	return hasher.Verify(password, passwordHash)
+}
+
+// CompareDummy costs as much as Compare against a hash of the current scheme and always
+// fails. Logins call it when there is no password to check, so the response time does not
+// tell whether the account exists.
+func CompareDummy(password string) bool {
+	hasher, hash := dummyHash()
+	if hash != "" {
+		hasher.Verify(password, hash)
+	}
+
+	return false
+}
+
+// dummyHash hashes a fixed password once per default hasher, so a Configure at startup
+// is picked up.
+func dummyHash() (Hasher, string) {
+	dummy.Lock()
+	defer dummy.Unlock()
+
+	if dummy.hasher != defaultHasher || dummy.hash == "" {
+		hash, err := defaultHasher.Hash("dummy-password")
+		if err != nil {
+			return defaultHasher, ""
+		}
+		dummy.hasher, dummy.hash = defaultHasher, hash
+	}
+
+	return dummy.hasher, dummy.hash
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func RandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("Error the generate random token")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
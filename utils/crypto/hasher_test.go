@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bcryptHash(t *testing.T, password string, cost int) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(hash)
+}
+
+func TestEncoderCompareRoundTrip(t *testing.T) {
+	hash, err := Encoder("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Encoder() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$") {
+		t.Fatalf("Encoder() = %q, want an argon2id hash", hash)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{name: "same password", password: "correct horse battery staple", want: true},
+		{name: "other password", password: "correct horse battery stapler", want: false},
+		{name: "empty password", password: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.password, hash); got != tt.want {
+				t.Fatalf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if other, _ := Encoder("correct horse battery staple"); other == hash {
+		t.Fatal("two hashes of the same password share a salt")
+	}
+}
+
+func TestEncoderRejectsEmptyPassword(t *testing.T) {
+	if _, err := Encoder(""); err == nil {
+		t.Fatal("Encoder(\"\") error = nil")
+	}
+}
+
+func TestCompareBcryptFallback(t *testing.T) {
+	hash := bcryptHash(t, "legacy-password", bcrypt.MinCost)
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{name: "bcrypt match", password: "legacy-password", hash: hash, want: true},
+		{name: "bcrypt mismatch", password: "wrong-password", hash: hash, want: false},
+		{name: "2y prefix", password: "legacy-password", hash: "$2y$" + strings.TrimPrefix(hash, "$2a$"), want: true},
+		{name: "unknown scheme", password: "legacy-password", hash: "$md5$abc", want: false},
+		{name: "malformed argon2id", password: "legacy-password", hash: "$argon2id$v=19$broken", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.password, tt.hash); got != tt.want {
+				t.Fatalf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current, err := Encoder("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weaker := DefaultArgon2Params
+	weaker.Memory = 8 * 1024
+	old, err := NewArgon2Hasher(weaker).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{name: "current parameters", hash: current, want: false},
+		{name: "other argon2id parameters", hash: old, want: true},
+		{name: "bcrypt", hash: bcryptHash(t, "password", bcrypt.MinCost), want: true},
+		{name: "garbage", hash: "not-a-hash", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !Compare("password", old) {
+		t.Fatal("a hash with other argon2id parameters no longer verifies")
+	}
+}
+
+func TestCompareDummyAlwaysFails(t *testing.T) {
+	for _, password := range []string{"", "dummy-password", "anything"} {
+		if CompareDummy(password) {
+			t.Fatalf("CompareDummy(%q) = true", password)
+		}
+	}
+}
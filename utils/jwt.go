@@ -1,83 +1,231 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 	"todolist-auth-fiber/models"
+	"todolist-auth-fiber/utils/keys"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtSecret string
+var keySet *keys.KeySet
 
 const (
-	AccessTokenExpiration  = time.Hour * 24
-	RefreshTokenExpiration = time.Hour * 24 * 7
+	AccessTokenExpiration            = time.Hour * 24
+	RefreshTokenExpiration           = time.Hour * 24 * 7
+	EmailVerificationTokenExpiration = time.Hour * 24
+	MfaPendingTokenExpiration        = time.Minute * 5
+	OAuthAccessTokenExpiration       = time.Hour
 )
 
-func init() {
-	if err := godotenv.Load(); err != nil {
-		panic(fmt.Errorf("error loading .env file: %v", err))
+const (
+	AccessTokenType            = "access"
+	RefreshTokenType           = "refresh"
+	EmailVerificationTokenType = "email_verification"
+	MfaPendingTokenType        = "mfa_pending"
+	DataExportTokenType        = "data_export"
+)
+
+type Claims struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Type      string `json:"type"`
+	SessionID string `json:"sid,omitempty"`
+	// CredentialsVersion is the user's credentials_version at issue time; bumping it invalidates the token.
+	CredentialsVersion int      `json:"cv"`
+	Role               string   `json:"role,omitempty"`
+	Permissions        []string `json:"permissions,omitempty"`
+	// ClientID and Scope are only set on tokens issued to OAuth clients (RFC 9068).
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// Act is only set on impersonation tokens and names the admin acting as the user.
+	Act *Actor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Actor is the RFC 8693 act claim.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// KeysFromEnv builds the signing keys from JWT_KEYS_DIR and JWT_ACTIVE_KID, or from
+// JWT_SECRET alone for a single HS256 key.
+func KeysFromEnv() (*keys.KeySet, error) {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+
+	if keysDir == "" {
+		if jwtSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS_DIR must be set in .env file")
+		}
+
+		return keys.NewHMAC("hs256", []byte(jwtSecret)), nil
 	}
 
-	jwtSecret = os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		panic("JWT_SECRET not set in .env file")
+	loaded, err := keys.LoadDir(keysDir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		return nil, fmt.Errorf("error loading JWT keys: %v", err)
 	}
+
+	if jwtSecret != "" {
+		loaded = loaded.WithLegacySecret([]byte(jwtSecret))
+	}
+
+	return loaded, nil
+}
+
+// ConfigureKeys sets the keys tokens are signed and verified with. It must be called
+// before any token is issued.
+func ConfigureKeys(set *keys.KeySet) {
+	keySet = set
+}
+
+func JWKS() keys.JWKS {
+	return keySet.JWKS()
 }
 
-func GenerateToken(user *models.User, expiration time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":  user.ID.Hex(),
-		"email":    user.Email,
-		"username": user.Username,
-		"exp":      time.Now().Add(expiration).Unix(),
+func NewClaims(user *models.User, sessionID primitive.ObjectID, tokenType string, expiration time.Duration) *Claims {
+	now := time.Now()
+	claims := &Claims{
+		UserID:             user.ID.Hex(),
+		Email:              user.Email,
+		Username:           user.Username,
+		Type:               tokenType,
+		CredentialsVersion: user.CredentialsVersion,
+		Role:               user.RoleName(),
+		Permissions:        user.Permissions(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		},
+	}
+
+	if !sessionID.IsZero() {
+		claims.SessionID = sessionID.Hex()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret))
+	return claims
 }
 
-func GenerateAccessToken(user *models.User) (string, error) {
-	return GenerateToken(user, AccessTokenExpiration)
+func SignClaims(claims *Claims) (string, error) {
+	return keySet.Sign(claims)
 }
 
-func GenerateRefreshToken(user *models.User) (string, error) {
-	return GenerateToken(user, RefreshTokenExpiration)
+func GenerateToken(user *models.User, sessionID primitive.ObjectID, tokenType string, expiration time.Duration) (string, error) {
+	return SignClaims(NewClaims(user, sessionID, tokenType, expiration))
 }
 
-func parseToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
+func GenerateAccessToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	return GenerateToken(user, sessionID, AccessTokenType, AccessTokenExpiration)
+}
+
+func GenerateRefreshToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	return GenerateToken(user, sessionID, RefreshTokenType, RefreshTokenExpiration)
 }
 
-func ExtractUserID(tokenString string) (primitive.ObjectID, error) {
-	token, err := parseToken(tokenString)
+// GenerateImpersonationToken issues an access token for user with actor in the act claim.
+// It has no session, so it cannot be refreshed and expires on its own.
+func GenerateImpersonationToken(user *models.User, actor *models.User, expiration time.Duration) (string, *Claims, error) {
+	claims := NewClaims(user, primitive.NilObjectID, AccessTokenType, expiration)
+	claims.Subject = user.ID.Hex()
+	claims.Act = &Actor{Subject: actor.ID.Hex()}
+
+	token, err := SignClaims(claims)
 	if err != nil {
-		return primitive.NilObjectID, fmt.Errorf("failed to parse token: %v", err)
+		return "", nil, err
+	}
+
+	return token, claims, nil
+}
+
+func ParseToken(tokenString string, tokenType string) (*Claims, error) {
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, keySet.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %v", err)
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if claims.ID == "" {
+		return nil, fmt.Errorf("jti not found in token claims")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return primitive.NilObjectID, fmt.Errorf("invalid token")
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("token is not a %s token", tokenType)
 	}
 
-	userIDHex, ok := claims["user_id"].(string)
-	if !ok {
+	return &claims, nil
+}
+
+func (claims *Claims) UserObjectID() (primitive.ObjectID, error) {
+	if claims.UserID == "" {
 		return primitive.NilObjectID, fmt.Errorf("user_id not found in token claims")
 	}
 
-	userID, err := primitive.ObjectIDFromHex(userIDHex)
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
 	if err != nil {
 		return primitive.NilObjectID, fmt.Errorf("invalid user_id in token claims: %v", err)
 	}
 
 	return userID, nil
 }
+
+func (claims *Claims) IsImpersonation() bool {
+	return claims.Act != nil
+}
+
+func (claims *Claims) ActorObjectID() (primitive.ObjectID, error) {
+	if claims.Act == nil || claims.Act.Subject == "" {
+		return primitive.NilObjectID, fmt.Errorf("act not found in token claims")
+	}
+
+	actorID, err := primitive.ObjectIDFromHex(claims.Act.Subject)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid act.sub in token claims: %v", err)
+	}
+
+	return actorID, nil
+}
+
+func (claims *Claims) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(claims.Scope) {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (claims *Claims) SessionObjectID() (primitive.ObjectID, error) {
+	if claims.SessionID == "" {
+		return primitive.NilObjectID, fmt.Errorf("sid not found in token claims")
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid sid in token claims: %v", err)
+	}
+
+	return sessionID, nil
+}
+
+func newTokenID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Errorf("failed to generate token id: %v", err))
+	}
+
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,320 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	secret  []byte
+}
+
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	legacy *Key
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadDir reads every "<kid>.pem" private key and "<kid>.pub.pem" public key in dir.
+// Private keys can sign and verify, public keys are kept to verify tokens signed by retired keys.
+func LoadDir(dir string, activeID string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys directory: %v", err)
+	}
+
+	set := &KeySet{keys: map[string]*Key{}}
+	signers := []string{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %v", name, err)
+		}
+
+		var key *Key
+		if strings.HasSuffix(name, publicKeySuffix) {
+			key, err = parsePublicKey(strings.TrimSuffix(name, publicKeySuffix), data)
+		} else {
+			key, err = parsePrivateKey(strings.TrimSuffix(name, privateKeySuffix), data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %v", name, err)
+		}
+
+		if _, exists := set.keys[key.ID]; exists && key.Private == nil {
+			continue
+		}
+
+		set.keys[key.ID] = key
+		if key.Private != nil {
+			signers = append(signers, key.ID)
+		}
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no private keys found in %s", dir)
+	}
+
+	if activeID == "" {
+		if len(signers) > 1 {
+			return nil, fmt.Errorf("several private keys found in %s, set the active key id", dir)
+		}
+		activeID = signers[0]
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("active key %q has no private key in %s", activeID, dir)
+	}
+
+	set.active = active
+	return set, nil
+}
+
+// NewSigner builds a key set around a single private key, for tools that mint their own tokens.
+func NewSigner(id string, private crypto.Signer) (*KeySet, error) {
+	var key *Key
+	switch signer := private.(type) {
+	case *rsa.PrivateKey:
+		key = &Key{ID: id, Method: jwt.SigningMethodRS256, Private: signer, Public: &signer.PublicKey}
+	case ed25519.PrivateKey:
+		key = &Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: signer, Public: signer.Public()}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	return &KeySet{active: key, keys: map[string]*Key{id: key}}, nil
+}
+
+func NewHMAC(id string, secret []byte) *KeySet {
+	key := &Key{ID: id, Method: jwt.SigningMethodHS256, secret: secret}
+	return &KeySet{
+		active: key,
+		keys:   map[string]*Key{id: key},
+		legacy: key,
+	}
+}
+
+// WithLegacySecret keeps accepting HMAC tokens issued without a kid header, so the
+// switch to asymmetric keys does not log everybody out.
+func (s *KeySet) WithLegacySecret(secret []byte) *KeySet {
+	s.legacy = &Key{Method: jwt.SigningMethodHS256, secret: secret}
+	return s
+}
+
+func (s *KeySet) ActiveID() string {
+	return s.active.ID
+}
+
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	token.Header["kid"] = s.active.ID
+
+	if s.active.secret != nil {
+		return token.SignedString(s.active.secret)
+	}
+
+	return token.SignedString(s.active.Private)
+}
+
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := s.legacy
+
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, ok = s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	if key == nil {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	if key.secret != nil {
+		return key.secret, nil
+	}
+
+	return key.Public, nil
+}
+
+func (s *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: []JWK{}}
+	for _, id := range ids {
+		key := s.keys[id]
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
+
+func parsePrivateKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: private, Public: private.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+func parsePublicKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Public: public}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", parsed)
+	}
+}
+
+// PublicKey decodes a public JWK published by another issuer. Symmetric keys are refused.
+func (k JWK) PublicKey() (*Key, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %v", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %v", err)
+		}
+
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return &Key{ID: k.Kid, Method: jwt.SigningMethodRS256, Public: public}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %v", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %v", err)
+		}
+
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &Key{ID: k.Kid, Method: jwt.SigningMethodES256, Public: public}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+
+		return &Key{ID: k.Kid, Method: jwt.SigningMethodEdDSA, Public: ed25519.PublicKey(x)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,214 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testKeys struct {
+	dir     string
+	current ed25519.PrivateKey
+	old     *rsa.PrivateKey
+}
+
+// newTestKeys writes an active Ed25519 private key "current" and the public half of a
+// retired RSA key "old".
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, "current.pem"), "PRIVATE KEY", der)
+
+	old, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(&old.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, "old.pub.pem"), "PUBLIC KEY", der)
+
+	return testKeys{dir: dir, current: current, old: old}
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signed(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "user"})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signedToken, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedToken
+}
+
+func TestKeySetKeyfunc(t *testing.T) {
+	keys := newTestKeys(t)
+
+	set, err := LoadDir(keys.dir, "")
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if set.ActiveID() != "current" {
+		t.Fatalf("ActiveID() = %q, want current", set.ActiveID())
+	}
+
+	active, err := set.Sign(jwt.MapClaims{"sub": "user"})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	legacySecret := []byte("legacy-secret")
+	withLegacy, err := LoadDir(keys.dir, "current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withLegacy.WithLegacySecret(legacySecret)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(keys.current.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		set     *KeySet
+		token   string
+		wantErr bool
+	}{
+		{name: "active key", set: set, token: active},
+		{name: "retired public key", set: set, token: signed(t, jwt.SigningMethodRS256, "old", keys.old)},
+		{name: "unknown kid", set: set, token: signed(t, jwt.SigningMethodRS256, "missing", keys.old), wantErr: true},
+		{name: "no kid without legacy secret", set: set, token: signed(t, jwt.SigningMethodHS256, "", legacySecret), wantErr: true},
+		{name: "no kid with legacy secret", set: withLegacy, token: signed(t, jwt.SigningMethodHS256, "", legacySecret)},
+		{name: "alg mismatch with kid", set: set, token: signed(t, jwt.SigningMethodRS256, "current", keys.old), wantErr: true},
+		{name: "public key used as HMAC secret", set: set, token: signed(t, jwt.SigningMethodHS256, "current", publicDER), wantErr: true},
+		{name: "legacy secret under a kid", set: withLegacy, token: signed(t, jwt.SigningMethodHS256, "current", legacySecret), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, tt.set.Keyfunc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	keys := newTestKeys(t)
+
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	severalDir := t.TempDir()
+	for _, name := range []string{"a.pem", "b.pem"} {
+		writePEM(t, filepath.Join(severalDir, name), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(second))
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		activeID string
+	}{
+		{name: "missing directory", dir: filepath.Join(keys.dir, "missing")},
+		{name: "no private key", dir: t.TempDir()},
+		{name: "several private keys without active id", dir: severalDir},
+		{name: "active key without private key", dir: keys.dir, activeID: "old"},
+		{name: "unknown active key", dir: keys.dir, activeID: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDir(tt.dir, tt.activeID); err == nil {
+				t.Fatal("LoadDir() error = nil")
+			}
+		})
+	}
+
+	if _, err := LoadDir(severalDir, "b"); err != nil {
+		t.Fatalf("LoadDir() with an active id error = %v", err)
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+
+	set, err := LoadDir(keys.dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := set.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+
+	want := []struct {
+		kid string
+		kty string
+		alg string
+		crv string
+	}{
+		{kid: "current", kty: "OKP", alg: "EdDSA", crv: "Ed25519"},
+		{kid: "old", kty: "RSA", alg: "RS256"},
+	}
+
+	for i, w := range want {
+		got := jwks.Keys[i]
+		if got.Kid != w.kid || got.Kty != w.kty || got.Alg != w.alg || got.Crv != w.crv || got.Use != "sig" {
+			t.Fatalf("JWKS().Keys[%d] = %+v, want kid %s kty %s alg %s crv %q", i, got, w.kid, w.kty, w.alg, w.crv)
+		}
+
+		public, err := got.PublicKey()
+		if err != nil {
+			t.Fatalf("PublicKey() for %s error = %v", w.kid, err)
+		}
+		if public.Method.Alg() != w.alg {
+			t.Fatalf("PublicKey() for %s alg = %s, want %s", w.kid, public.Method.Alg(), w.alg)
+		}
+	}
+
+	published, _ := jwks.Keys[1].PublicKey()
+	token := signed(t, jwt.SigningMethodRS256, "old", keys.old)
+	if _, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return published.Public, nil }); err != nil {
+		t.Fatalf("published RSA key does not verify a token: %v", err)
+	}
+
+	if got := NewHMAC("hs256", []byte("secret")).JWKS(); len(got.Keys) != 0 {
+		t.Fatalf("HMAC JWKS() = %+v, want no keys", got.Keys)
+	}
+}
@@ -0,0 +1,18 @@
+package mappers
+
+import (
+	exportdto "todolist-auth-fiber/dtos/exportDto"
+	"todolist-auth-fiber/models"
+)
+
+func DataExportToDataExportDTO(export *models.DataExport) exportdto.DataExportDTO {
+	return exportdto.DataExportDTO{
+		ID:          export.ID,
+		Status:      export.Status,
+		Error:       export.Error,
+		Size:        export.Size,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
@@ -0,0 +1,16 @@
+package mappers
+
+import (
+	identitydto "todolist-auth-fiber/dtos/identityDto"
+	"todolist-auth-fiber/models"
+)
+
+func IdentityToIdentityDTO(identity *models.UserIdentity) identitydto.IdentityDTO {
+	return identitydto.IdentityDTO{
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		CreatedAt:   identity.CreatedAt,
+		LastLoginAt: identity.LastLoginAt,
+	}
+}
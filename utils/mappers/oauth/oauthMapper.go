@@ -0,0 +1,17 @@
+package mappers
+
+import (
+	oauthdto "todolist-auth-fiber/dtos/oauthDto"
+	"todolist-auth-fiber/models"
+)
+
+func ClientToClientDTO(client *models.OAuthClient) oauthdto.ClientDTO {
+	return oauthdto.ClientDTO{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Confidential: client.Confidential(),
+		CreatedAt:    client.CreatedAt,
+	}
+}
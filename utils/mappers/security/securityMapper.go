@@ -0,0 +1,17 @@
+package mappers
+
+import (
+	securitydto "todolist-auth-fiber/dtos/securityDto"
+	"todolist-auth-fiber/models"
+)
+
+func SecurityEventToSecurityEventDTO(event *models.SecurityEvent) securitydto.SecurityEventDTO {
+	return securitydto.SecurityEventDTO{
+		ID:        event.ID,
+		Type:      event.Type,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt,
+	}
+}
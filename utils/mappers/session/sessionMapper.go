@@ -0,0 +1,20 @@
+package mappers
+
+import (
+	sessiondto "todolist-auth-fiber/dtos/sessionDto"
+	"todolist-auth-fiber/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func SessionToSessionDTO(session *models.Session, currentID primitive.ObjectID) sessiondto.SessionDTO {
+	return sessiondto.SessionDTO{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		ClientID:   session.ClientID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		Current:    session.ID == currentID,
+	}
+}
@@ -0,0 +1,17 @@
+package mappers
+
+import (
+	tokendto "todolist-auth-fiber/dtos/tokenDto"
+	"todolist-auth-fiber/models"
+)
+
+func PersonalAccessTokenToDTO(token *models.PersonalAccessToken) tokendto.PersonalAccessTokenDTO {
+	return tokendto.PersonalAccessTokenDTO{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
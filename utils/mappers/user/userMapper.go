@@ -7,9 +7,23 @@ import (
 
 func UserToUserDTO(user *models.User) userDto.UserDTO {
 	return userDto.UserDTO{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		TOTPEnabled:   user.TOTPEnabled,
+		Role:          user.RoleName(),
+		CreatedAt:     user.CreatedAt,
 	}
-}
\ No newline at end of file
+}
+
+func UserToAdminUserDTO(user *models.User) userDto.AdminUserDTO {
+	return userDto.AdminUserDTO{
+		UserDTO:    UserToUserDTO(user),
+		Disabled:   user.Disabled,
+		DisabledAt: user.DisabledAt,
+		DeletedAt:  user.DeletedAt,
+		PurgeAt:    user.PurgeAt,
+		UpdatedAt:  user.UpdatedAt,
+	}
+}
@@ -0,0 +1,148 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrInvalidScope            = "invalid_scope"
+	ErrAccessDenied            = "access_denied"
+	ErrServerError             = "server_error"
+)
+
+const PKCEMethodS256 = "S256"
+
+// Error is an RFC 6749 error. Status is the HTTP status used when the error is returned
+// directly. Errors with a RedirectURI are sent back to the client through its redirect URI.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+	RedirectURI string `json:"-"`
+	State       string `json:"-"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+
+	return e.Code + ": " + e.Description
+}
+
+func NewError(status int, code string, description string) *Error {
+	return &Error{Code: code, Description: description, Status: status}
+}
+
+// Redirect turns the error into one delivered through the client's redirect URI.
+func (e *Error) Redirect(redirectURI string, state string) *Error {
+	e.RedirectURI = redirectURI
+	e.State = state
+	return e
+}
+
+func (e *Error) RedirectTo() string {
+	params := url.Values{}
+	params.Set("error", e.Code)
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if e.State != "" {
+		params.Set("state", e.State)
+	}
+
+	return AppendQuery(e.RedirectURI, params)
+}
+
+// AppendQuery adds params to uri, keeping the query the client registered.
+func AppendQuery(uri string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+
+	return uri + separator + params.Encode()
+}
+
+// ValidRedirectURI accepts the redirect URIs RFC 8252 allows: https, http on a loopback
+// address for native apps, and private-use schemes named after a reverse domain such as
+// com.example.app:/callback. Schemes like javascript:, data: or file: are refused, since
+// the browser would run or open them with the authorization code attached.
+func ValidRedirectURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil || !parsed.IsAbs() || strings.Contains(uri, "#") {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return parsed.Host != ""
+	case "http":
+		return isLoopback(parsed.Hostname())
+	}
+
+	return strings.Contains(parsed.Scheme, ".")
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// VerifyPKCE checks a code_verifier against the S256 code_challenge stored with the code (RFC 7636).
+func VerifyPKCE(verifier string, challenge string, method string) bool {
+	if method != PKCEMethodS256 || len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// PKCEChallenge derives the S256 code_challenge of verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// RFC 7636 appendix B.
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	if got := PKCEChallenge(verifier); got != challenge {
+		t.Fatalf("PKCEChallenge() = %s, want %s", got, challenge)
+	}
+
+	shortest := strings.Repeat("a", 43)
+	longest := strings.Repeat("a", 128)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		method    string
+		want      bool
+	}{
+		{name: "RFC 7636 example", verifier: verifier, challenge: challenge, method: PKCEMethodS256, want: true},
+		{name: "wrong verifier", verifier: strings.Replace(verifier, "d", "e", 1), challenge: challenge, method: PKCEMethodS256, want: false},
+		{name: "plain method refused", verifier: verifier, challenge: verifier, method: "plain", want: false},
+		{name: "empty method refused", verifier: verifier, challenge: challenge, method: "", want: false},
+		{name: "verifier as challenge", verifier: verifier, challenge: verifier, method: PKCEMethodS256, want: false},
+		{name: "43 characters", verifier: shortest, challenge: PKCEChallenge(shortest), method: PKCEMethodS256, want: true},
+		{name: "42 characters", verifier: shortest[1:], challenge: PKCEChallenge(shortest[1:]), method: PKCEMethodS256, want: false},
+		{name: "128 characters", verifier: longest, challenge: PKCEChallenge(longest), method: PKCEMethodS256, want: true},
+		{name: "129 characters", verifier: longest + "a", challenge: PKCEChallenge(longest + "a"), method: PKCEMethodS256, want: false},
+		{name: "empty challenge", verifier: verifier, challenge: "", method: PKCEMethodS256, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPKCE(tt.verifier, tt.challenge, tt.method); got != tt.want {
+				t.Fatalf("VerifyPKCE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{uri: "https://app.example.com/callback", want: true},
+		{uri: "https://app.example.com/callback?tenant=1", want: true},
+		{uri: "http://127.0.0.1:8080/callback", want: true},
+		{uri: "http://[::1]:8080/callback", want: true},
+		{uri: "http://localhost:3000/callback", want: true},
+		{uri: "com.example.app:/callback", want: true},
+		{uri: "http://app.example.com/callback", want: false},
+		{uri: "http://localhost.example.com/callback", want: false},
+		{uri: "https:///callback", want: false},
+		{uri: "https://app.example.com/callback#done", want: false},
+		{uri: "https://app.example.com/callback#", want: false},
+		{uri: "/callback", want: false},
+		{uri: "javascript:alert(1)", want: false},
+		{uri: "JavaScript:alert(1)", want: false},
+		{uri: "data:text/html,<script>alert(1)</script>", want: false},
+		{uri: "file:///etc/passwd", want: false},
+		{uri: "myapp:/callback", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if got := ValidRedirectURI(tt.uri); got != tt.want {
+				t.Fatalf("ValidRedirectURI(%q) = %v, want %v", tt.uri, got, tt.want)
+			}
+		})
+	}
+}
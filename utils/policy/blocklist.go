@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const hashPrefixLength = 5
+
+// Blocklist holds SHA-1 hashes of breached passwords bucketed by their first five hex
+// characters, the same range layout used by Have I Been Pwned, so a lookup only ever
+// compares suffixes within one bucket.
+type Blocklist struct {
+	ranges map[string]map[string]struct{}
+	size   int
+}
+
+// LoadBlocklist reads a file with one uppercase or lowercase SHA-1 hash per line,
+// optionally followed by ":<count>" as in the Pwned Passwords downloads. Blank lines
+// and lines starting with # are ignored.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	blocklist := &Blocklist{ranges: map[string]map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("password blocklist %s:%d: expected a SHA-1 hex hash", path, line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("password blocklist %s:%d: %w", path, line, err)
+		}
+
+		blocklist.add(hash)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fail to read password blocklist: %w", err)
+	}
+
+	return blocklist, nil
+}
+
+func (b *Blocklist) add(hash string) {
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	bucket, ok := b.ranges[prefix]
+	if !ok {
+		bucket = map[string]struct{}{}
+		b.ranges[prefix] = bucket
+	}
+
+	if _, ok := bucket[suffix]; !ok {
+		bucket[suffix] = struct{}{}
+		b.size++
+	}
+}
+
+func (b *Blocklist) Len() int {
+	return b.size
+}
+
+func (b *Blocklist) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, ok := b.ranges[hash[:hashPrefixLength]][hash[hashPrefixLength:]]
+	return ok
+}
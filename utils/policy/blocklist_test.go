@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newBlocklist(passwords ...string) *Blocklist {
+	blocklist := &Blocklist{ranges: map[string]map[string]struct{}{}}
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		blocklist.add(strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	return blocklist
+}
+
+func writeBlocklist(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	// SHA-1 of "password" in upper case with a Pwned Passwords count, and of "123456"
+	// in lower case.
+	path := writeBlocklist(t, strings.Join([]string{
+		"# breached passwords",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"",
+		"7c4a8d09ca3762af61e59520943dc26494f8941b",
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8",
+	}, "\n"))
+
+	blocklist, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatalf("LoadBlocklist() error = %v", err)
+	}
+
+	if blocklist.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", blocklist.Len())
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{password: "password", want: true},
+		{password: "123456", want: true},
+		{password: "Password", want: false},
+		{password: "password1", want: false},
+		{password: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := blocklist.Contains(tt.password); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBlocklistRejectsMalformedLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "short hash", content: "5BAA61E4C9B93F3F"},
+		{name: "not hex", content: strings.Repeat("Z", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadBlocklist(writeBlocklist(t, tt.content)); err == nil {
+				t.Fatal("LoadBlocklist() error = nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"todolist-auth-fiber/utils/apperr"
+	"unicode"
+	"unicode/utf8"
+)
+
+type PasswordPolicy struct {
+	MinLength  int
+	MaxLength  int
+	MinClasses int
+	Blocklist  *Blocklist
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MaxLength:  128,
+	MinClasses: 3,
+}
+
+var passwordPolicy = DefaultPasswordPolicy
+
+func Configure(policy PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+// Violation is one reason a password was rejected. Code is stable so clients can
+// translate it, Message is a readable fallback.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Rejected is the error answered for a password that breaks the policy, with the
+// violations as the response body.
+func Rejected(violations []Violation) error {
+	return apperr.Unprocessable("Password does not meet the password policy").WithDetails(violations)
+}
+
+// CheckPassword returns every rule the password breaks, or nil when it is accepted.
+func CheckPassword(password string, username string, email string) []Violation {
+	return passwordPolicy.Check(password, username, email)
+}
+
+// ValidatePassword is CheckPassword for callers that propagate errors.
+func ValidatePassword(password string, username string, email string) error {
+	if violations := CheckPassword(password, username, email); len(violations) > 0 {
+		return Rejected(violations)
+	}
+
+	return nil
+}
+
+func (p PasswordPolicy) Check(password string, username string, email string) []Violation {
+	violations := []Violation{}
+	length := utf8.RuneCountInString(password)
+
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Code:    "too_short",
+			Message: fmt.Sprintf("Password must have at least %d characters", p.MinLength),
+		})
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{
+			Code:    "too_long",
+			Message: fmt.Sprintf("Password must have at most %d characters", p.MaxLength),
+		})
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violations = append(violations, Violation{
+			Code:    "too_few_character_classes",
+			Message: fmt.Sprintf("Password must mix at least %d of lowercase, uppercase, digits and symbols", p.MinClasses),
+		})
+	}
+
+	lower := strings.ToLower(password)
+
+	if username = strings.ToLower(strings.TrimSpace(username)); len(username) >= 3 && strings.Contains(lower, username) {
+		violations = append(violations, Violation{
+			Code:    "contains_username",
+			Message: "Password must not contain the username",
+		})
+	}
+
+	local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if len(local) >= 3 && strings.Contains(lower, local) {
+		violations = append(violations, Violation{
+			Code:    "contains_email",
+			Message: "Password must not contain the email address",
+		})
+	}
+
+	if p.Blocklist != nil && p.Blocklist.Contains(password) {
+		violations = append(violations, Violation{
+			Code:    "breached",
+			Message: "Password appears in a list of breached passwords",
+		})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return violations
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+
+	return classes
+}
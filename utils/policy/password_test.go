@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violationCodes(violations []Violation) []string {
+	var codes []string
+	for _, violation := range violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	policy.Blocklist = newBlocklist("Passw0rd!")
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		email    string
+		want     []string
+	}{
+		{name: "accepted", password: "Tr1cky-Horse", username: "ada", email: "ada@example.com", want: nil},
+		{name: "exactly min length", password: "Abcdef1!", want: nil},
+		{name: "one below min length", password: "Abcde1!", want: []string{"too_short"}},
+		{name: "counts runes not bytes", password: "Äbcdé1!", want: []string{"too_short"}},
+		{name: "exactly max length", password: "Aa1" + strings.Repeat("x", 125), want: nil},
+		{name: "one above max length", password: "Aa1" + strings.Repeat("x", 126), want: []string{"too_long"}},
+		{name: "too few classes", password: "alllowercase1", want: []string{"too_few_character_classes"}},
+		{name: "contains username", password: "xAdalove1!", username: "Ada", want: []string{"contains_username"}},
+		{name: "short username ignored", password: "xAblove1!", username: "ab", want: nil},
+		{name: "contains email local part", password: "Grace.hopper1", email: "grace.hopper@example.com", want: []string{"contains_email"}},
+		{name: "blocklisted", password: "Passw0rd!", want: []string{"breached"}},
+		{name: "every rule", password: "ada", username: "ada", email: "ada@example.com", want: []string{"too_short", "too_few_character_classes", "contains_username", "contains_email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationCodes(policy.Check(tt.password, tt.username, tt.email))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyWithoutMaxLength(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 1, MinClasses: 1}
+
+	if got := policy.Check(strings.Repeat("a", 10000), "", ""); got != nil {
+		t.Fatalf("Check() = %v, want no violations", violationCodes(got))
+	}
+}
@@ -0,0 +1,6 @@
+package res
+
+type ResponseMfa struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+}
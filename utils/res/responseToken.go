@@ -3,4 +3,5 @@ package res
 type ResponseToken struct {
 	Token        string   `json:"token"`
 	RefreshToken string   `json:"refresh_token"`
-}
\ No newline at end of file
+	Notices      []string `json:"notices,omitempty"`
+}